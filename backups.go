@@ -0,0 +1,196 @@
+package mcsmanager
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TimeFormat is the layout used to name backup archives. It is an
+// ISO-8601 timestamp, so archive names sort in chronological order.
+const TimeFormat = "2006-01-02T15:04:05-0700"
+
+// Backup describes a single archive in a backup directory.
+type Backup struct {
+	Name string
+	Path string
+	Time time.Time
+	Size int64
+}
+
+// ListBackups finds all of the backup archives in the given directory.
+// The returned list is sorted so the oldest backup is first.
+//
+// Files that don't look like an archive created by the backup command
+// are ignored.
+func ListBackups(dir string) ([]Backup, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	backups := make([]Backup, 0)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		timestamp := TrimArchiveExt(entry.Name())
+		if timestamp == entry.Name() {
+			continue
+		}
+
+		t, err := time.Parse(TimeFormat, timestamp)
+		if err != nil {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		backups = append(backups, Backup{
+			Name: entry.Name(),
+			Path: filepath.Join(dir, entry.Name()),
+			Time: t,
+			Size: info.Size(),
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Time.Before(backups[j].Time)
+	})
+
+	return backups, nil
+}
+
+// FindBackup looks for a backup in a list of backups. The id can either
+// be "latest", the full name of the archive, or its timestamp. A leading
+// portion of the timestamp, such as "2021-09-02", is also accepted as long
+// as only one backup matches it.
+func FindBackup(backups []Backup, id string) (Backup, error) {
+	if len(backups) == 0 {
+		return Backup{}, fmt.Errorf("no backups found")
+	}
+
+	if id == "latest" {
+		return backups[len(backups)-1], nil
+	}
+
+	matches := make([]Backup, 0)
+	for _, backup := range backups {
+		timestamp := TrimArchiveExt(backup.Name)
+		if backup.Name == id || timestamp == id {
+			return backup, nil
+		}
+		if strings.HasPrefix(timestamp, id) {
+			matches = append(matches, backup)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return Backup{}, fmt.Errorf("no backup matches '%s'", id)
+	case 1:
+		return matches[0], nil
+	default:
+		return Backup{}, fmt.Errorf("%d backups match '%s', please be more specific", len(matches), id)
+	}
+}
+
+// TrimArchiveExt removes a known archive extension from the end of
+// a file name. If the name has no known extension, it is returned as-is.
+func TrimArchiveExt(name string) string {
+	for _, ext := range compressionExtensions {
+		if strings.HasSuffix(name, ext) {
+			return strings.TrimSuffix(name, ext)
+		}
+	}
+
+	return name
+}
+
+// PruneBackups removes the oldest backup archives in a directory until the
+// number of archives is one under the limit, making room for a new backup.
+// Only files with a known archive extension are counted or removed.
+func PruneBackups(dir string, maxBackups int) (total int, err error) {
+	if maxBackups <= 0 { // -1 or 0 to disable pruning
+		return
+	}
+
+	// We should be at the limit after the new backup
+	return removeOldest(dir, maxBackups-1)
+}
+
+// KeepBackups removes the oldest backup archives in a directory until there
+// are at most maxBackups left. Only files with a known archive extension are
+// counted or removed.
+func KeepBackups(dir string, maxBackups int) (total int, err error) {
+	if maxBackups <= 0 { // -1 or 0 to disable pruning
+		return
+	}
+
+	return removeOldest(dir, maxBackups)
+}
+
+// removeOldest removes the oldest backup archives in a directory until
+// there are only the given number left.
+func removeOldest(dir string, keep int) (total int, err error) {
+	backups, err := ListBackups(dir)
+	if err != nil {
+		return
+	}
+
+	for i := 0; i < len(backups)-keep; i++ {
+		if err = removeBackup(backups[i]); err != nil {
+			return
+		}
+		total++
+	}
+
+	return
+}
+
+// PruneOldBackups removes the backup archives in a directory that are older
+// than the max age in days. Only files with a known archive extension are
+// removed.
+func PruneOldBackups(dir string, maxAge int) (total int, err error) {
+	if maxAge == -1 { // -1 to disable age pruning
+		return
+	}
+
+	backups, err := ListBackups(dir)
+	if err != nil {
+		return
+	}
+
+	cur := time.Now()
+	for _, backup := range backups {
+		if cur.Sub(backup.Time).Hours() > float64(maxAge*24) {
+			if err = removeBackup(backup); err != nil {
+				return
+			}
+			total++
+		}
+	}
+
+	return
+}
+
+// removeBackup deletes a backup archive along with its manifest.
+func removeBackup(backup Backup) error {
+	if err := os.Remove(backup.Path); err != nil {
+		return err
+	}
+
+	if err := os.Remove(ManifestPath(backup.Path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
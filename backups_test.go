@@ -0,0 +1,90 @@
+package mcsmanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPruneBackups(t *testing.T) {
+	// Create temp dir to test in
+	dir := t.TempDir()
+
+	files := []string{
+		"2021-09-01T10:00:00-0500.tar",
+		"2021-09-02T10:00:00-0500.tar.gz",
+		"2021-09-03T10:00:00-0500.tar.zst",
+		"2021-09-04T10:00:00-0500.tar.xz",
+		"notes.txt",
+	}
+
+	// Create our test files
+	for _, file := range files {
+		path := filepath.Join(dir, file)
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatalf("error creating test file: %s\n", err)
+		}
+		f.Close()
+	}
+
+	result, err := PruneBackups(dir, 3)
+	if err != nil {
+		t.Fatalf("error pruning backups: %s\n", err)
+	}
+
+	// Check if the result is correct
+	if result != 2 {
+		t.Fatalf("pruned wrong number of files: expected 2, pruned %d", result)
+	}
+	for _, file := range []string{"2021-09-03T10:00:00-0500.tar.zst", "2021-09-04T10:00:00-0500.tar.xz", "notes.txt"} {
+		if _, err := os.Stat(filepath.Join(dir, file)); err != nil {
+			t.Fatalf("expected file '%s' to exist: %s", file, err)
+		}
+	}
+}
+
+func TestKeepBackups(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		"2021-09-01T10:00:00-0500.tar",
+		"2021-09-02T10:00:00-0500.tar.gz",
+		"2021-09-03T10:00:00-0500.tar.zst",
+		"2021-09-04T10:00:00-0500.tar.xz",
+	}
+	for _, file := range files {
+		if err := os.WriteFile(filepath.Join(dir, file), nil, 0644); err != nil {
+			t.Fatalf("error creating test file: %s\n", err)
+		}
+	}
+
+	// Exactly the limit is kept, without making room for a new backup
+	result, err := KeepBackups(dir, 3)
+	if err != nil {
+		t.Fatalf("error pruning backups: %s\n", err)
+	}
+	if result != 1 {
+		t.Fatalf("pruned wrong number of files: expected 1, pruned %d", result)
+	}
+
+	backups, err := ListBackups(dir)
+	if err != nil {
+		t.Fatalf("error listing backups: %s\n", err)
+	}
+	if len(backups) != 3 || backups[0].Name != files[1] {
+		t.Fatalf("expected the 3 newest backups to be kept, got %v", backups)
+	}
+
+	if result, err = KeepBackups(dir, 0); err != nil || result != 0 {
+		t.Fatalf("expected nothing to be pruned with a limit of 0, pruned %d (%v)", result, err)
+	}
+}
+
+func TestPruneBackupsDisabled(t *testing.T) {
+	dir := t.TempDir()
+	for _, max := range []int{-1, 0} {
+		if result, err := PruneBackups(dir, max); err != nil || result != 0 {
+			t.Fatalf("expected nothing to be pruned with a limit of %d, pruned %d (%v)", max, result, err)
+		}
+	}
+}
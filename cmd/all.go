@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"github.com/DataDrake/cli-ng/v2/cmd"
+	"github.com/EbonJaeger/mcsmanager/config"
+)
+
+// How commands are run with `--all`.
+const (
+	// allSequential runs the command for each server, one at a time.
+	allSequential = iota
+
+	// allParallel runs the command for every server at once, for commands
+	// that keep running until they are stopped.
+	allParallel
+
+	// allNative leaves it up to the command to handle every server.
+	allNative
+
+	// allUnsupported is for commands that only make sense for one server.
+	allUnsupported
+)
+
+// allModes says how each command is run with `--all`. Commands that aren't
+// listed are run one server at a time.
+var allModes = map[string]int{
+	"attach":    allUnsupported,
+	"console":   allUnsupported,
+	"init":      allUnsupported,
+	"daemon":    allParallel,
+	"supervise": allParallel,
+	"exporter":  allNative,
+	"list":      allNative,
+	"network":   allNative,
+}
+
+// allResult is the result of a command for one server, when it is run
+// with `--all` and JSON or YAML output.
+type allResult struct {
+	Server string      `json:"server" yaml:"server"`
+	Path   string      `json:"path" yaml:"path"`
+	Result interface{} `json:"result,omitempty" yaml:"result,omitempty"`
+	Error  string      `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// ForAll wraps the Run function of a command, so it is run for every server
+// in the inventory when the `--all` flag is given.
+func ForAll(sub *cmd.Sub) {
+	run := sub.Run
+	sub.Run = func(root *cmd.Root, c *cmd.Sub) {
+		if !root.Flags.(*GlobalFlags).All || allModes[c.Name] == allNative {
+			run(root, c)
+			return
+		}
+
+		runAll(root, c)
+	}
+}
+
+// runAll runs a command for every server in the inventory by running
+// mcsmanager again for each of them. Exits with an error if the command
+// failed for any server.
+func runAll(root *cmd.Root, c *cmd.Sub) {
+	flags := root.Flags.(*GlobalFlags)
+	mode := allModes[c.Name]
+	if mode == allUnsupported {
+		Log.Fatalf("The %s command can only be used with one server\n", c.Name)
+	}
+	if flags.Path != "" || flags.Server != "" {
+		Log.Fatalln("--all can't be used together with --path or --server")
+	}
+
+	servers := inventoryServers()
+	if len(servers) == 0 {
+		return
+	}
+
+	// Run the same command line for each server, without `--all`
+	args := make([]string, 0, len(os.Args))
+	for _, arg := range os.Args[1:] {
+		if arg != "--all" {
+			args = append(args, arg)
+		}
+	}
+
+	// Collect the results of each server into one document
+	structured := flags.IsStructured()
+	if structured {
+		args = append(args, "-O", OutputJSON)
+	}
+
+	results := make([]allResult, len(servers))
+	outputs := make([]bytes.Buffer, len(servers))
+	commands := make([]*exec.Cmd, len(servers))
+
+	// start starts the command for a server
+	start := func(i int) {
+		server := servers[i]
+		results[i] = allResult{Server: server.Name, Path: server.Path}
+
+		command, err := selfCommand(server.Path, args...)
+		if err == nil {
+			command.Stdin = os.Stdin
+			if structured {
+				command.Stdout = &outputs[i]
+			}
+			err = command.Start()
+		}
+		if err != nil {
+			results[i].Error = err.Error()
+			Log.Errorf("Unable to run the command for '%s': %s\n", server.Name, err)
+			return
+		}
+		commands[i] = command
+	}
+
+	// wait waits for the command for a server to finish, and reads its result
+	wait := func(i int) {
+		if commands[i] == nil {
+			return
+		}
+		if err := commands[i].Wait(); err != nil {
+			results[i].Error = err.Error()
+			Log.Errorf("Command failed for '%s': %s\n", servers[i].Name, err)
+		}
+		if structured && outputs[i].Len() > 0 {
+			if err := json.Unmarshal(outputs[i].Bytes(), &results[i].Result); err != nil {
+				results[i].Error = fmt.Sprintf("unable to read result: %s", err)
+			}
+		}
+	}
+
+	if mode == allParallel {
+		// Pass signals on, so every server is stopped cleanly
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(signals)
+
+		for i := range servers {
+			start(i)
+		}
+		go func() {
+			for sig := range signals {
+				for _, command := range commands {
+					if command != nil {
+						command.Process.Signal(sig)
+					}
+				}
+			}
+		}()
+		for i := range servers {
+			wait(i)
+		}
+	} else {
+		for i, server := range servers {
+			if !structured {
+				fmt.Printf("%s==> %s%s\n", blue, server.Name, reset)
+			}
+			start(i)
+			wait(i)
+		}
+	}
+
+	writeResult(root, results)
+	for _, result := range results {
+		if result.Error != "" {
+			os.Exit(1)
+		}
+	}
+}
+
+// inventoryServers loads the servers in the inventory, warning the user if
+// there aren't any.
+func inventoryServers() []*config.InventoryServer {
+	inv, err := config.LoadInventory()
+	if err != nil {
+		Log.Fatalf("Error loading the server inventory: %s\n", err)
+	}
+
+	servers := inv.List()
+	if len(servers) == 0 {
+		path, _ := config.InventoryPath()
+		Log.Warnf("There are no servers in the inventory at '%s'\n", path)
+	}
+
+	return servers
+}
@@ -41,6 +41,7 @@ func AttachToSession(root *cmd.Root, c *cmd.Sub) {
 	Log.Infoln("Attention!")
 	Log.Infoln("To leave the console, press Ctrl+B then 'd'")
 	Log.Warnln("Warning! Do not press Ctrl+C to exit! You will force-close your server!")
+	Log.Infoln("Tip: 'mcsmanager console' opens a console that is safe to close with Ctrl+C")
 	Log.Println("")
 	Log.Print("     Continue? [y/N] ")
 
@@ -2,10 +2,14 @@ package cmd
 
 import (
 	"archive/tar"
-	"compress/gzip"
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/DataDrake/cli-ng/v2/cmd"
@@ -14,9 +18,45 @@ import (
 	"github.com/EbonJaeger/mcsmanager/tmux"
 )
 
+// saveTimeout is how long to wait for the server to confirm that
+// the world has been saved before a live backup.
+const saveTimeout = 60 * time.Second
+
+const (
+	// setsDir is the directory inside of the backup directory that
+	// archives of backup sets are kept in, one directory per set.
+	setsDir = "sets"
+
+	// worldsDir is the directory inside of the backup directory that
+	// archives of single worlds are kept in, one directory per world.
+	worldsDir = "worlds"
+)
+
 // BackupFlags holds the flags for the backup command.
 type BackupFlags struct {
-	Level int `short:"l" long:"level" desc:"Set the compression format to use; 0: no compression; 1: gzip"`
+	Level int    `short:"l" long:"level" desc:"Set the compression format to use, overriding the config; 0: no compression; 1: gzip; 2: zstd; 3: xz"`
+	Live  bool   `short:"o" long:"online" desc:"Back up the server while it is running by pausing world saving"`
+	World string `short:"w" long:"world" arg:"true" desc:"Only back up the world with the given name"`
+	Set   string `short:"S" long:"set" arg:"true" desc:"Only back up the files in the backup set with the given name"`
+}
+
+// backupSelection is the part of the server that a backup covers, and
+// where its archives are kept.
+type backupSelection struct {
+	Dir        string
+	Paths      []string
+	MaxBackups int
+	MaxAge     int
+}
+
+// IsFull checks if the selection covers the entire server.
+func (s backupSelection) IsFull() bool {
+	return s.Paths == nil
+}
+
+// BackupArgs contains the command arguments for the backup command.
+type BackupArgs struct {
+	Args []string `zero:"true" desc:"Use \"verify [backup]\" to check the integrity of all backups, or just the given one"`
 }
 
 // Backup archives the Minecraft server files.
@@ -24,17 +64,34 @@ var Backup = cmd.Sub{
 	Name:  "backup",
 	Alias: "b",
 	Short: "Backup all server files into a tar archive with optional compression",
-	Flags: &BackupFlags{},
+	Args:  &BackupArgs{},
+	Flags: &BackupFlags{Level: -1},
 	Run:   ArchiveServer,
 }
 
 // ArchiveServer adds all directories and files of the server into a tar archive with optional compression.
+//
+// If the server is running and the online flag is set, world saving is turned
+// off while the files are archived so the world files aren't changed in the
+// middle of the backup. Saving is always turned back on afterwards, even if
+// the backup is interrupted, in which case the partial archive is removed.
 func ArchiveServer(root *cmd.Root, c *cmd.Sub) {
-	level := c.Flags.(*BackupFlags).Level
-	if level < 0 || level > 1 {
-		Log.Fatalln("Compression level must be between 0 and 1")
+	if args := c.Args.(*BackupArgs).Args; len(args) > 0 {
+		if args[0] != "verify" || len(args) > 2 {
+			Log.Errorln("Unknown arguments!")
+			Log.Errorln("")
+			Log.Errorln("USAGE:")
+			Log.Errorf("\tmcsmanager %s\n", c.Name)
+			Log.Errorln("OR")
+			Log.Errorf("\tmcsmanager %s verify [backup]\n", c.Name)
+			os.Exit(1)
+		}
+		VerifyBackups(root, c.Flags.(*BackupFlags), args[1:])
+		return
 	}
 
+	flags := c.Flags.(*BackupFlags)
+
 	prefix, err := root.Flags.(*GlobalFlags).GetPathPrefix()
 	if err != nil {
 		Log.Fatalf("Error getting the working directory: %s\n", err)
@@ -45,33 +102,188 @@ func ArchiveServer(root *cmd.Root, c *cmd.Sub) {
 		Log.Fatalf("Error loading server config: %s\n", err)
 	}
 
+	// Use the compression from the config unless we were given a level
+	compression := conf.BackupSettings.Compression
+	if flags.Level != -1 {
+		if compression, err = mcsmanager.CompressionFromLevel(flags.Level); err != nil {
+			Log.Fatalf("Invalid compression level: %s\n", err)
+		}
+	}
+	if _, err = mcsmanager.ArchiveExt(compression); err != nil {
+		Log.Fatalf("Invalid compression format: %s\n", err)
+	}
+
+	// Figure out which files to back up
+	selection := getSelection(conf, prefix, flags)
+	repository := conf.BackupSettings.Backend == config.BackendRepository
+	if repository && !selection.IsFull() {
+		Log.Fatalln("World and backup set backups are only supported for archives")
+	}
+
+	name := conf.MainSettings.ServerName
+
 	// Check if the server is currently running
-	if tmux.IsServerRunning(conf.MainSettings.ServerName) {
+	running := tmux.IsServerRunning(name)
+	if running && !flags.Live {
 		Log.Warnln("Please stop the server before trying to archive it!")
+		Log.Warnln("To back up a running server, use the '--online' flag.")
 		return
 	}
 
+	// Check if the backup directory exists
+	backupDir := selection.Dir
+	if _, err := os.Stat(backupDir); os.IsNotExist(err) {
+		Log.Infoln("Backup directory does not exist! Creating it...")
+		if err = os.MkdirAll(backupDir, 0755); err != nil {
+			Log.Fatalf("Unable to create backups directory: %s\n", err)
+		}
+		Log.Goodln("Backup directory created!")
+	}
+
+	// Make room for the new archive
+	if !repository {
+		pruneArchives(backupDir, selection.MaxBackups, selection.MaxAge, true)
+	}
+
+	// Stop archiving instead of exiting right away if we are interrupted,
+	// so saving is turned back on and the partial archive is cleaned up
+	ctx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	// Make sure the world is saved and stays untouched while we archive it
+	if running {
+		Log.Infoln("Server is running! Saving the world and pausing world saving...")
+		if err = disableSaving(prefix, name); err != nil {
+			enableSaving(prefix, name)
+			Log.Fatalf("Unable to save the world: %s\n", err)
+		}
+		Log.Goodln("World saved!")
+	}
+
 	Log.Infoln("Archiving server files...")
 
-	// Get our backup directory path
-	var backupDir string
-	if filepath.IsAbs(conf.BackupSettings.BackupDir) {
-		backupDir = conf.BackupSettings.BackupDir
+	// Add all of the server files to the archive
+	start := time.Now()
+	var archive string
+	if repository {
+		err = writeSnapshot(prefix, backupDir, conf)
 	} else {
-		backupDir = filepath.Join(prefix, conf.BackupSettings.BackupDir)
+		exclusions := append(mcsmanager.SelectPaths(selection.Paths...), getExclusions(conf, prefix)...)
+		archive, err = writeBackup(ctx, prefix, backupDir, conf, compression, exclusions)
 	}
+	diff := time.Since(start)
 
-	// Check if the backup directory exists
-	if _, err := os.Stat(backupDir); os.IsNotExist(err) {
-		Log.Infoln("Backup directory does not exist! Creating it...")
-		if err = os.Mkdir(backupDir, 0755); err != nil {
-			Log.Fatalln("Unable to create backups directory: %s\n", err)
+	// Stopping the signals cancels the context too, so check it first
+	interrupted := ctx.Err() != nil
+	stopSignals()
+
+	if running {
+		enableSaving(prefix, name)
+	}
+
+	if interrupted {
+		Log.Fatalln("Backup stopped by signal")
+	}
+	if err != nil {
+		Log.Fatalf("Error adding files to archive: %s\n", err)
+	}
+	Log.Goodf("Server backup archive created in %v\n", diff)
+
+	// Only prune snapshots once the new one is safely stored
+	if repository {
+		pruneRepository(conf, backupDir)
+		if len(conf.BackupSettings.Targets) > 0 {
+			Log.Warnln("Backup targets are only supported for archives, skipping uploads")
+		}
+		return
+	}
+
+	// Only full backups are copied, since targets are pruned as one set of backups
+	if !selection.IsFull() {
+		if len(conf.BackupSettings.Targets) > 0 {
+			Log.Infoln("Backup targets only receive full backups, skipping uploads")
+		}
+		return
+	}
+
+	// Copy the archive to any other backup targets
+	if !uploadBackup(conf, archive) {
+		os.Exit(1)
+	}
+}
+
+// getSelection returns the part of the server to back up according to the
+// world and set flags. Without either flag, the entire server is backed up.
+func getSelection(conf config.Root, prefix string, flags *BackupFlags) backupSelection {
+	backupDir := getBackupDir(conf, prefix)
+	selection := backupSelection{
+		Dir:        backupDir,
+		MaxBackups: conf.BackupSettings.MaxBackups,
+		MaxAge:     conf.BackupSettings.MaxAge,
+	}
+
+	switch {
+	case flags.World != "" && flags.Set != "":
+		Log.Fatalln("Only one of '--world' or '--set' can be used at a time")
+	case flags.World != "":
+		worlds, err := mcsmanager.Worlds(prefix)
+		if err != nil {
+			Log.Fatalf("Unable to find the server worlds: %s\n", err)
+		}
+
+		found := false
+		for _, world := range worlds {
+			found = found || world == flags.World
+		}
+		if !found {
+			Log.Fatalf("No world found with the name '%s'. Worlds: %s\n", flags.World, strings.Join(worlds, ", "))
+		}
+
+		selection.Dir = filepath.Join(backupDir, worldsDir, flags.World)
+		selection.Paths = []string{flags.World}
+	case flags.Set != "":
+		set, ok := findSet(conf, flags.Set)
+		if !ok {
+			Log.Fatalf("No backup set found with the name '%s'\n", flags.Set)
+		}
+
+		selection.Dir = filepath.Join(backupDir, setsDir, set.Name)
+		selection.Paths = append([]string{}, set.Paths...)
+		selection.MaxBackups, selection.MaxAge = conf.BackupSettings.SetLimits(set)
+
+		if set.Worlds {
+			worlds, err := mcsmanager.Worlds(prefix)
+			if err != nil {
+				Log.Fatalf("Unable to find the server worlds: %s\n", err)
+			}
+			selection.Paths = append(selection.Paths, worlds...)
+		}
+
+		if len(selection.Paths) == 0 {
+			Log.Fatalf("Backup set '%s' doesn't have any files to back up\n", set.Name)
+		}
+	}
+
+	return selection
+}
+
+// findSet looks up a backup set in the config by its name.
+func findSet(conf config.Root, name string) (config.BackupSet, bool) {
+	for _, set := range conf.BackupSettings.Sets {
+		// Set names are used as directory names
+		if set.Name == name && filepath.Base(name) == name {
+			return set, true
 		}
-		Log.Goodln("Backup directory created!")
 	}
 
+	return config.BackupSet{}, false
+}
+
+// pruneArchives removes backup archives in a directory that are too old, and
+// then the oldest archives until there are at most maxBackups left, or until
+// there is room for a new one if makeRoom is set.
+func pruneArchives(backupDir string, maxBackups, maxAge int, makeRoom bool) {
 	// Check for backups that are too old
-	if pruned, err := mcsmanager.PruneOld(backupDir, conf.BackupSettings.MaxAge); err == nil {
+	if pruned, err := mcsmanager.PruneOldBackups(backupDir, maxAge); err == nil {
 		if pruned > 0 {
 			Log.Infof("Removed %d archive(s) due to age.\n", pruned)
 		}
@@ -80,62 +292,215 @@ func ArchiveServer(root *cmd.Root, c *cmd.Sub) {
 	}
 
 	// Check for too many backups
-	if pruned, err := mcsmanager.Prune(backupDir, conf.BackupSettings.MaxBackups); err == nil {
+	prune := mcsmanager.KeepBackups
+	if makeRoom {
+		prune = mcsmanager.PruneBackups
+	}
+	if pruned, err := prune(backupDir, maxBackups); err == nil {
 		if pruned > 0 {
 			Log.Infof("Removed %d archive(s) because over backup limit.\n", pruned)
 		}
 	} else {
 		Log.Fatalf("Unable to remove old backups: %s\n", err)
 	}
+}
 
-	exclusions := append(*conf.BackupSettings.ExcludedPaths, conf.BackupSettings.BackupDir)
+// pruneRepository removes snapshots from the backup repository that are too
+// old or over the backup limit, along with any chunks that are no longer used.
+func pruneRepository(conf config.Root, backupDir string) {
+	repo, err := mcsmanager.OpenRepository(filepath.Join(backupDir, mcsmanager.RepositoryDir))
+	if err != nil {
+		Log.Fatalf("Unable to open backup repository: %s\n", err)
+	}
 
-	// Create archive file
-	tarFile, err := createArchive(backupDir, level)
+	snapshots, chunks, err := repo.Prune(conf.BackupSettings.MaxBackups, conf.BackupSettings.MaxAge)
 	if err != nil {
-		Log.Fatalf("Error while adding files to archive: %s\n", err)
+		Log.Fatalf("Unable to remove old backups: %s\n", err)
 	}
-	defer tarFile.Close()
 
-	// Create our file writers
-	var w *tar.Writer
-	switch level {
-	case 0:
-		w = tar.NewWriter(tarFile)
-	case 1:
-		compressor, err := gzip.NewWriterLevel(tarFile, gzip.BestCompression)
+	if snapshots > 0 {
+		Log.Infof("Removed %d snapshot(s) and %d unused chunk(s).\n", snapshots, chunks)
+	}
+}
+
+// writeSnapshot stores all of the server files that aren't excluded in the
+// backup repository. Only chunks that aren't in the repository yet are written.
+func writeSnapshot(prefix, backupDir string, conf config.Root) error {
+	exclusions := getExclusions(conf, prefix)
+
+	repo, err := mcsmanager.OpenRepository(filepath.Join(backupDir, mcsmanager.RepositoryDir))
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.Snapshot(prefix, exclusions...)
+	return err
+}
+
+// writeBackup creates a new archive in the backup directory containing all of
+// the server files that aren't excluded, returning the path to the archive.
+// If anything goes wrong or the context is cancelled, the partial archive
+// is removed.
+func writeBackup(ctx context.Context, prefix, backupDir string, conf config.Root, compression string, exclusions []string) (path string, err error) {
+	// Create archive file
+	tarFile, err := createArchive(backupDir, compression)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		tarFile.Close()
 		if err != nil {
-			Log.Fatalln("Failed to create archive compressor: %s\n", err)
+			os.Remove(tarFile.Name())
 		}
-		defer compressor.Close()
-		w = tar.NewWriter(compressor)
+	}()
+
+	// Create our file writers
+	opts := mcsmanager.CompressionOptions{
+		Level:   conf.BackupSettings.CompressionLevel,
+		Threads: conf.BackupSettings.CompressionThreads,
+	}
+	compressor, err := mcsmanager.NewCompressor(tarFile, compression, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to create archive compressor: %s", err)
 	}
-	defer w.Close()
+	w := tar.NewWriter(cancelWriter{ctx: ctx, w: compressor})
 
-	// Add all of the server files to the archive
-	start := time.Now()
-	err = mcsmanager.Archive(prefix, w, exclusions...)
-	diff := time.Since(start)
+	manifest, err := mcsmanager.Archive(prefix, w, exclusions...)
+	if err != nil {
+		return "", err
+	}
+
+	// Flush everything to disk
+	if err = w.Close(); err != nil {
+		return "", err
+	}
+	if err = compressor.Close(); err != nil {
+		return "", err
+	}
+
+	if err = tarFile.Sync(); err != nil {
+		return "", err
+	}
+
+	// Save the checksums of the archived files for the verify command
+	if err = manifest.Save(mcsmanager.ManifestPath(tarFile.Name())); err != nil {
+		return "", fmt.Errorf("unable to save archive manifest: %s", err)
+	}
 
+	return tarFile.Name(), nil
+}
+
+// disableSaving tells the server to stop writing to the world files, and then
+// to save everything to disk. This waits until the server says the world has
+// been saved.
+func disableSaving(prefix, name string) error {
+	logPath := filepath.Join(prefix, "logs", "latest.log")
+	offset, err := mcsmanager.LogSize(logPath)
 	if err != nil {
-		Log.Errorf("Error adding files to archive: %s\n", err)
+		return err
 	}
-	Log.Goodf("Server backup archive created in %v\n", diff)
+
+	if _, err = sendCommand(prefix, name, "save-off"); err != nil {
+		return err
+	}
+	reply, err := sendCommand(prefix, name, "save-all flush")
+	if err != nil {
+		return err
+	}
+
+	// Over RCON, the reply comes once the world is saved
+	if reply != nil && strings.Contains(*reply, "Saved the game") {
+		return nil
+	}
+
+	return mcsmanager.WaitForLog(logPath, offset, "Saved the game", saveTimeout)
+}
+
+// cancelWriter is a writer that fails once its context is cancelled, which
+// stops an archive that is being written.
+type cancelWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+// Write writes to the underlying writer if the context isn't cancelled yet.
+func (c cancelWriter) Write(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return c.w.Write(p)
+}
+
+// enableSaving turns world saving back on for a running server.
+func enableSaving(prefix, name string) {
+	if _, err := sendCommand(prefix, name, "save-on"); err != nil {
+		Log.Errorf("Unable to turn world saving back on! Run 'save-on' in the server console: %s\n", err)
+		return
+	}
+
+	Log.Infoln("World saving turned back on")
 }
 
-func createArchive(dir string, level int) (*os.File, error) {
+// loadBackups lists all of the backups in the backup directory. If the server
+// uses a backup repository, the repository is returned along with its snapshots.
+func loadBackups(conf config.Root, backupDir string) (*mcsmanager.Repository, []mcsmanager.Backup) {
+	repo, backups, err := listBackups(conf, backupDir)
+	if err != nil {
+		Log.Fatalf("Error reading backups: %s\n", err)
+	}
+
+	return repo, backups
+}
+
+// listBackups lists the backups in the backup directory or repository.
+func listBackups(conf config.Root, backupDir string) (*mcsmanager.Repository, []mcsmanager.Backup, error) {
+	if conf.BackupSettings.Backend != config.BackendRepository {
+		backups, err := mcsmanager.ListBackups(backupDir)
+		return nil, backups, err
+	}
+
+	repo, err := mcsmanager.OpenRepository(filepath.Join(backupDir, mcsmanager.RepositoryDir))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	backups, err := repo.Backups()
+	return repo, backups, err
+}
+
+// getExclusions returns the patterns for files that should not be backed up.
+// These are the excluded paths from the config, and the backup directory
+// itself if it is inside of the server directory.
+func getExclusions(conf config.Root, prefix string) []string {
+	exclusions := append([]string{}, *conf.BackupSettings.ExcludedPaths...)
+
+	rel, err := filepath.Rel(prefix, getBackupDir(conf, prefix))
+	if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		exclusions = append(exclusions, "/"+filepath.ToSlash(rel))
+	}
+
+	return exclusions
+}
+
+// getBackupDir returns the full path to the directory that backups
+// are stored in.
+func getBackupDir(conf config.Root, prefix string) string {
+	if filepath.IsAbs(conf.BackupSettings.BackupDir) {
+		return conf.BackupSettings.BackupDir
+	}
+
+	return filepath.Join(prefix, conf.BackupSettings.BackupDir)
+}
+
+func createArchive(dir string, compression string) (*os.File, error) {
 	currentTime := time.Now()
-	timeStr := currentTime.Format("2006-01-02T15:04:05-0700") // ISO-8601 format
+	timeStr := currentTime.Format(mcsmanager.TimeFormat)
 
-	var path string
-	switch level {
-	case 0: // No compression
-		path = filepath.Join(dir, timeStr+".tar")
-	case 1: // gzip compression
-		path = filepath.Join(dir, timeStr+".tar.gz")
-	default:
-		return nil, fmt.Errorf("compression level not supported: %d", level)
+	ext, err := mcsmanager.ArchiveExt(compression)
+	if err != nil {
+		return nil, err
 	}
 
-	return os.Create(path)
+	return os.Create(filepath.Join(dir, timeStr+ext))
 }
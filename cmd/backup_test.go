@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/EbonJaeger/mcsmanager"
+	"github.com/EbonJaeger/mcsmanager/config"
+)
+
+func TestWriteBackupCancelled(t *testing.T) {
+	prefix := t.TempDir()
+	if err := os.WriteFile(filepath.Join(prefix, "server.properties"), []byte("motd=A Minecraft Server"), 0644); err != nil {
+		t.Fatalf("error creating test file: %s\n", err)
+	}
+	backupDir := t.TempDir()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := writeBackup(ctx, prefix, backupDir, config.Default(), mcsmanager.CompressionGzip, nil); err == nil {
+		t.Fatal("expected an error writing a backup with a cancelled context")
+	}
+
+	entries, err := os.ReadDir(backupDir)
+	if err != nil {
+		t.Fatalf("error reading backup dir: %s\n", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected the partial archive to be removed, got %d files", len(entries))
+	}
+}
@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDrake/cli-ng/v2/cmd"
+	"github.com/EbonJaeger/mcsmanager/config"
+	"github.com/EbonJaeger/mcsmanager/console"
+	"github.com/EbonJaeger/mcsmanager/rcon"
+	"github.com/EbonJaeger/mcsmanager/tmux"
+	"golang.org/x/term"
+)
+
+// playersTTL is how long the list of online players is cached for tab
+// completion.
+const playersTTL = 5 * time.Second
+
+// Console opens an interactive console over RCON.
+var Console = cmd.Sub{
+	Name:  "console",
+	Alias: "c",
+	Short: "Open an interactive server console over RCON",
+	Run:   OpenConsole,
+}
+
+// OpenConsole reads commands from the user and sends them to the server
+// over RCON, printing the server's replies. Unlike attaching to the tmux
+// window, pressing Ctrl+C or Ctrl+D only closes the console.
+func OpenConsole(root *cmd.Root, c *cmd.Sub) {
+	prefix, err := root.Flags.(*GlobalFlags).GetPathPrefix()
+	if err != nil {
+		Log.Fatalf("Error getting the working directory: %s\n", err)
+	}
+
+	conf, err := config.Load(prefix)
+	if err != nil {
+		Log.Fatalf("Error loading server config: %s\n", err)
+	}
+
+	name := conf.MainSettings.ServerName
+	if !tmux.IsServerRunning(name) {
+		Log.Warnln("Server is not currently running!")
+		return
+	}
+
+	settings, err := rcon.LoadSettings(prefix)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		Log.Fatalf("Error reading server.properties: %s\n", err)
+	}
+	if !settings.Enabled {
+		Log.Errorln("RCON is not enabled for this server!")
+		Log.Fatalln("Set 'enable-rcon=true' and an 'rcon.password' in server.properties, and restart the server")
+	}
+
+	client, err := rcon.Dial(settings.Address, settings.Password)
+	if err != nil {
+		Log.Fatalf("Unable to connect to the server: %s\n", err)
+	}
+	defer client.Close()
+
+	// Read commands from a pipe without any line editing
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		if err = runCommands(client, os.Stdin, os.Stdout); err != nil {
+			Log.Fatalf("Error while sending command: %s\n", err)
+		}
+		return
+	}
+
+	Log.Goodf("Connected to '%s'\n", name)
+	Log.Infoln("Press Tab to complete commands and player names, and Ctrl+C or Ctrl+D to leave")
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		Log.Fatalf("Unable to set up the terminal: %s\n", err)
+	}
+	err = readCommands(client, fd)
+	term.Restore(fd, state)
+
+	if err != nil {
+		Log.Fatalf("Error while sending command: %s\n", err)
+	}
+	Log.Goodln("Closed server console!")
+}
+
+// readCommands runs the interactive console in a terminal that is in raw
+// mode, until the user leaves or the server closes the connection.
+func readCommands(client *rcon.Client, fd int) error {
+	screen := struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}
+	t := term.NewTerminal(screen, "> ")
+	if width, height, err := term.GetSize(fd); err == nil {
+		t.SetSize(width, height)
+	}
+
+	completer := console.Completer{
+		Commands: console.Commands,
+		Players:  cachedPlayers(client),
+	}
+	t.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+		return completer.Complete(line, pos)
+	}
+
+	for {
+		// Ctrl+C and Ctrl+D both end the input
+		line, err := t.ReadLine()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				fmt.Fprint(t, "\n")
+				return nil
+			}
+			return err
+		}
+
+		done, err := runCommand(client, line, t)
+		if done || err != nil {
+			return err
+		}
+	}
+}
+
+// runCommands sends each line that is read to the server.
+func runCommands(client *rcon.Client, r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		done, err := runCommand(client, scanner.Text(), w)
+		if done || err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// runCommand sends a line to the server and prints its reply. Returns true
+// if the console should be closed, because the server is stopping.
+func runCommand(client *rcon.Client, line string, w io.Writer) (bool, error) {
+	command := strings.TrimPrefix(strings.TrimSpace(line), "/")
+	if command == "" {
+		return false, nil
+	}
+
+	reply, err := client.Execute(command)
+	if err != nil {
+		return false, err
+	}
+
+	if reply = strings.TrimRight(console.StripColors(reply), "\n"); reply != "" {
+		fmt.Fprintln(w, reply)
+	}
+
+	return command == "stop", nil
+}
+
+// cachedPlayers returns a function that lists the players that are online,
+// only asking the server again once the list is a few seconds old.
+func cachedPlayers(client *rcon.Client) func() []string {
+	var mu sync.Mutex
+	var players []string
+	var fetched time.Time
+
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if time.Since(fetched) > playersTTL {
+			reply, err := client.Execute("list")
+			if err != nil {
+				return players
+			}
+			players, fetched = console.ParsePlayers(reply), time.Now()
+		}
+
+		return players
+	}
+}
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/DataDrake/cli-ng/v2/cmd"
+	"github.com/EbonJaeger/mcsmanager/config"
+	"github.com/EbonJaeger/mcsmanager/schedule"
+	"github.com/EbonJaeger/mcsmanager/tmux"
+)
+
+// Daemon runs scheduled jobs for a Minecraft server.
+var Daemon = cmd.Sub{
+	Name:  "daemon",
+	Alias: "d",
+	Short: "Run the scheduled backups, restarts, and commands from the config until stopped",
+	Run:   RunDaemon,
+}
+
+// RunDaemon runs the jobs in the schedule section of the config until it is
+// interrupted. Jobs never run at the same time as another job for the same
+// server, so a backup never overlaps with a restart.
+//
+// Backups are run as separate mcsmanager processes, so a job that fails
+// can't take down the daemon with it. If the supervisor is enabled in the
+// config, the server is watched for crashes as well.
+func RunDaemon(root *cmd.Root, c *cmd.Sub) {
+	prefix, err := root.Flags.(*GlobalFlags).GetPathPrefix()
+	if err != nil {
+		Log.Fatalf("Error getting the working directory: %s\n", err)
+	}
+
+	conf, err := config.Load(prefix)
+	if err != nil {
+		Log.Fatalf("Error loading server config: %s\n", err)
+	}
+
+	scheduler := schedule.New()
+	scheduler.OnError = func(job schedule.Job, err error) {
+		Log.Errorf("Scheduled %s failed: %s\n", job.Name, err)
+	}
+	if err = addJobs(scheduler, conf, prefix); err != nil {
+		Log.Fatalf("Invalid schedule: %s\n", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Watch for crashes at the same time, if enabled
+	var wg sync.WaitGroup
+	if conf.Supervisor.Enabled {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := superviseServer(ctx, conf, prefix); err != nil {
+				Log.Errorf("Invalid supervisor settings: %s\n", err)
+			}
+		}()
+	}
+
+	Log.Goodf("Scheduler started for '%s'\n", conf.MainSettings.ServerName)
+	scheduler.Run(ctx)
+	wg.Wait()
+	Log.Infoln("Scheduler stopped")
+}
+
+// addJobs adds all of the scheduled jobs from the config to a scheduler.
+// An error is returned if there are no jobs, or any of them are invalid.
+func addJobs(scheduler *schedule.Scheduler, conf config.Root, prefix string) error {
+	name := conf.MainSettings.ServerName
+	count := 0
+
+	// add parses the cron expression of a job and adds it to the scheduler
+	add := func(expr string, job schedule.Job) error {
+		cron, err := schedule.Parse(expr)
+		if err != nil {
+			return fmt.Errorf("bad cron expression for %s: %s", job.Name, err)
+		}
+		job.Schedule = cron
+		job.Key = name
+
+		Log.Infof("Next %s at %s\n", job.Name, scheduler.Next(job, time.Now()).Add(job.Lead).Format(time.RFC1123))
+		scheduler.Add(job)
+		count++
+		return nil
+	}
+
+	for _, backup := range conf.Schedule.Backups {
+		args := []string{"backup"}
+		if backup.Online {
+			args = append(args, "-o")
+		}
+		if backup.World != "" {
+			args = append(args, "-w", backup.World)
+		}
+		if backup.Set != "" {
+			args = append(args, "-S", backup.Set)
+		}
+
+		err := add(backup.Cron, schedule.Job{
+			Name: "backup",
+			Run: func(ctx context.Context, at time.Time) error {
+				Log.Infoln("Running scheduled backup...")
+				return runSelf(prefix, args...)
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, restart := range conf.Schedule.Restarts {
+		counter, err := newCountdown(conf, restart.Warnings, restart.Message)
+		if err != nil {
+			return err
+		}
+
+		between := make([][]string, 0)
+		if restart.Backup {
+			between = append(between, []string{"backup"})
+		}
+
+		// Start early enough to send the first warning
+		lead := time.Duration(0)
+		if len(counter.Warnings) > 0 {
+			lead = counter.Warnings[0]
+		}
+
+		err = add(restart.Cron, schedule.Job{
+			Name: "restart",
+			Lead: lead,
+			Run: func(ctx context.Context, at time.Time) error {
+				return restartServer(ctx, prefix, conf, at, counter, between...)
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, command := range conf.Schedule.Commands {
+		commands := command.Commands
+		err := add(command.Cron, schedule.Job{
+			Name: "command",
+			Run: func(ctx context.Context, at time.Time) error {
+				if !tmux.IsServerRunning(name) {
+					Log.Infoln("Server is not running, skipping scheduled commands")
+					return nil
+				}
+				for _, command := range commands {
+					if _, err := sendCommand(prefix, name, command); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if count == 0 && !conf.Supervisor.Enabled {
+		return fmt.Errorf("there are no jobs in the schedule section of the config")
+	}
+
+	return nil
+}
+
+// runSelf runs another mcsmanager command for the server, with its
+// output going to our output.
+func runSelf(prefix string, args ...string) error {
+	command, err := selfCommand(prefix, args...)
+	if err != nil {
+		return err
+	}
+
+	return command.Run()
+}
+
+// selfCommand creates a command that runs mcsmanager again for a server.
+func selfCommand(prefix string, args ...string) (*exec.Cmd, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	args = append(append([]string{}, args...), "-p", prefix)
+	command := exec.Command(self, args...)
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+
+	return command, nil
+}
@@ -1,8 +1,13 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"os"
+
 	"github.com/DataDrake/cli-ng/v2/cmd"
 	"github.com/EbonJaeger/mcsmanager/config"
+	"github.com/EbonJaeger/mcsmanager/rcon"
 	"github.com/EbonJaeger/mcsmanager/tmux"
 )
 
@@ -44,10 +49,42 @@ func Execute(root *cmd.Root, c *cmd.Sub) {
 	args := c.Args.(*ExecArgs)
 
 	// Send the command to the server
-	err = tmux.Exec(args.Command, name)
+	reply, err := sendCommand(prefix, name, args.Command)
 	if err != nil {
-		Log.Fatalf("Error while sending command: %s", err.Error())
-	} else {
+		Log.Fatalf("Error while sending command: %s\n", err)
+	}
+
+	if reply == nil {
 		Log.Goodln("Command sent successfully!")
+	} else if *reply != "" {
+		fmt.Println(*reply)
+	}
+}
+
+// sendCommand runs a command on the server. If RCON is enabled in
+// server.properties, the command is sent over RCON and the server's reply is
+// returned. Otherwise, the command is typed into the server's tmux window,
+// and the reply is nil since there's no way to get it.
+func sendCommand(prefix, name, command string) (*string, error) {
+	settings, err := rcon.LoadSettings(prefix)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if !settings.Enabled {
+		return nil, tmux.Exec(command, name)
 	}
+
+	client, err := rcon.Dial(settings.Address, settings.Password)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to RCON: %s", err)
+	}
+	defer client.Close()
+
+	reply, err := client.Execute(command)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reply, nil
 }
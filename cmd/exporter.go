@@ -0,0 +1,306 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/DataDrake/cli-ng/v2/cmd"
+	"github.com/EbonJaeger/mcsmanager"
+	"github.com/EbonJaeger/mcsmanager/config"
+	"github.com/EbonJaeger/mcsmanager/metrics"
+	"github.com/EbonJaeger/mcsmanager/ping"
+	"github.com/EbonJaeger/mcsmanager/properties"
+	"github.com/EbonJaeger/mcsmanager/provider"
+	"github.com/EbonJaeger/mcsmanager/tmux"
+)
+
+const (
+	// defaultListen is the address the exporter listens on by default.
+	defaultListen = ":9225"
+
+	// sizeInterval is how often the size of the worlds is measured, since
+	// walking large worlds on every scrape would be slow.
+	sizeInterval = 5 * time.Minute
+)
+
+// ExporterFlags holds the flags for the exporter command.
+type ExporterFlags struct {
+	Listen string `short:"l" long:"listen" arg:"true" desc:"Address to serve the metrics on, defaults to \":9225\""`
+}
+
+// Exporter serves metrics about the Minecraft server for Prometheus.
+var Exporter = cmd.Sub{
+	Name:  "exporter",
+	Alias: "x",
+	Short: "Serve metrics about the Minecraft server for Prometheus",
+	Flags: &ExporterFlags{},
+	Run:   RunExporter,
+}
+
+// exporter collects the metrics of the servers when it is scraped.
+type exporter struct {
+	servers []*serverExporter
+}
+
+// serverExporter collects the metrics of a single server.
+type serverExporter struct {
+	prefix string
+
+	// World sizes are cached between scrapes
+	mu       sync.Mutex
+	sizes    map[string]int64
+	measured time.Time
+}
+
+// RunExporter serves the metrics on `/metrics` until it is interrupted. With
+// `--all`, the metrics of every server in the inventory are served.
+func RunExporter(root *cmd.Root, c *cmd.Sub) {
+	flags := root.Flags.(*GlobalFlags)
+	e := &exporter{}
+	if flags.All {
+		for _, server := range inventoryServers() {
+			e.servers = append(e.servers, &serverExporter{prefix: server.Path})
+		}
+		if len(e.servers) == 0 {
+			os.Exit(1)
+		}
+	} else {
+		prefix, err := flags.GetPathPrefix()
+		if err != nil {
+			Log.Fatalf("Error getting the working directory: %s\n", err)
+		}
+
+		// Make sure that we're in a server directory
+		if _, err = config.Load(prefix); err != nil {
+			Log.Fatalf("Error loading server config: %s\n", err)
+		}
+		e.servers = append(e.servers, &serverExporter{prefix: prefix})
+	}
+
+	listen := c.Flags.(*ExporterFlags).Listen
+	if listen == "" {
+		listen = defaultListen
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", e)
+	server := &http.Server{Addr: listen, Handler: mux}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdown)
+	}()
+
+	Log.Goodf("Serving metrics on %s/metrics\n", listen)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		Log.Fatalf("Error serving metrics: %s\n", err)
+	}
+	Log.Infoln("Exporter stopped")
+}
+
+// ServeHTTP collects the metrics and writes them in the Prometheus format.
+// Servers whose config can't be loaded are left out.
+func (e *exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	registry := metrics.NewRegistry()
+	for _, server := range e.servers {
+		if err := server.collect(registry); err != nil {
+			Log.Warnf("Unable to collect metrics for '%s': %s\n", server.prefix, err)
+		}
+	}
+
+	w.Header().Set("Content-Type", metrics.ContentType)
+	registry.Write(w)
+}
+
+// collect adds the metrics of the server to a registry. Metrics that can't
+// be collected are left out, and a warning is logged.
+func (e *serverExporter) collect(r *metrics.Registry) error {
+	conf, err := config.Load(e.prefix)
+	if err != nil {
+		return err
+	}
+	name := conf.MainSettings.ServerName
+	label := []string{"server", name}
+
+	props, err := properties.Load(e.prefix)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		Log.Warnf("Unable to read server.properties: %s\n", err)
+	}
+
+	running := tmux.IsServerRunning(name)
+	r.Gauge("mcsmanager_server_up", "Whether the server window is open").Set(boolValue(running), label...)
+
+	// Ask the server itself how it's doing
+	if running {
+		address := net.JoinHostPort(props.Get("server-ip", "127.0.0.1"), props.Get("server-port", strconv.Itoa(ping.DefaultPort)))
+		status, err := ping.Ping(address, pingTimeout)
+		r.Gauge("mcsmanager_server_ping_success", "Whether the server answered a status ping").Set(boolValue(err == nil), label...)
+		if err == nil {
+			r.Gauge("mcsmanager_players_online", "Number of players online").Set(float64(status.Players.Online), label...)
+			r.Gauge("mcsmanager_players_max", "Maximum number of players").Set(float64(status.Players.Max), label...)
+			r.Gauge("mcsmanager_ping_latency_seconds", "Time the server took to answer a ping").Set(status.Latency.Seconds(), label...)
+		}
+
+		if proc, err := serverProcess(name); err != nil {
+			Log.Warnf("Unable to find the server process: %s\n", err)
+		} else {
+			r.Gauge("mcsmanager_process_resident_memory_bytes", "Resident memory of the server process").Set(float64(proc.ResidentMemory), label...)
+			r.Counter("mcsmanager_process_cpu_seconds_total", "CPU time used by the server process").Set(proc.CPUSeconds, label...)
+		}
+	}
+
+	// Size of each world
+	worldSize := r.Gauge("mcsmanager_world_size_bytes", "Size of a world directory")
+	sizes := e.worldSizes()
+	worlds := make([]string, 0, len(sizes))
+	for world := range sizes {
+		worlds = append(worlds, world)
+	}
+	sort.Strings(worlds)
+	for _, world := range worlds {
+		worldSize.Set(float64(sizes[world]), "server", name, "world", world)
+	}
+
+	// Backups in the backup directory, and of each backup set and world. They
+	// all have the same labels, with the kind of backup and the name of its
+	// set or world.
+	backupDir := getBackupDir(conf, e.prefix)
+	_, backups, err := listBackups(conf, backupDir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		Log.Warnf("Unable to list backups: %s\n", err)
+	} else {
+		setBackupMetrics(r, backups, "server", name, "kind", "full", "name", "")
+	}
+	for _, kind := range []struct{ dir, label string }{{setsDir, "set"}, {worldsDir, "world"}} {
+		entries, err := os.ReadDir(filepath.Join(backupDir, kind.dir))
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				Log.Warnf("Unable to list backups: %s\n", err)
+			}
+			continue
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+
+			backups, err := mcsmanager.ListBackups(filepath.Join(backupDir, kind.dir, entry.Name()))
+			if err != nil {
+				Log.Warnf("Unable to list backups: %s\n", err)
+				continue
+			}
+			setBackupMetrics(r, backups, "server", name, "kind", kind.label, "name", entry.Name())
+		}
+	}
+
+	if updated, ok := lastUpdate(e.prefix, conf.MainSettings.ServerFile); ok {
+		r.Gauge("mcsmanager_last_update_timestamp_seconds", "Time the server was last updated").Set(float64(updated.Unix()), label...)
+	}
+
+	return nil
+}
+
+// lastUpdate returns the time the server was last updated. That is when the
+// server jar or the state file of an update provider was last written, since
+// providers that run an installer, like Forge, leave the jar alone.
+func lastUpdate(prefix, serverFile string) (updated time.Time, ok bool) {
+	for _, name := range append([]string{serverFile}, provider.StateFiles()...) {
+		info, err := os.Stat(filepath.Join(prefix, name))
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(updated) {
+			updated, ok = info.ModTime(), true
+		}
+	}
+
+	return
+}
+
+// setBackupMetrics adds the number, size, and age of a list of backups to
+// a registry, with the given labels.
+func setBackupMetrics(r *metrics.Registry, backups []mcsmanager.Backup, label ...string) {
+	var total int64
+	for _, backup := range backups {
+		total += backup.Size
+	}
+	r.Gauge("mcsmanager_backups", "Number of backups in the backup directory").Set(float64(len(backups)), label...)
+	r.Gauge("mcsmanager_backups_size_bytes", "Total size of the backups in the backup directory").Set(float64(total), label...)
+
+	if len(backups) > 0 {
+		oldest, latest := backups[0], backups[len(backups)-1]
+		r.Gauge("mcsmanager_last_backup_timestamp_seconds", "Time of the latest successful backup").Set(float64(latest.Time.Unix()), label...)
+		r.Gauge("mcsmanager_last_backup_size_bytes", "Size of the latest successful backup").Set(float64(latest.Size), label...)
+		r.Gauge("mcsmanager_oldest_backup_timestamp_seconds", "Time of the oldest backup").Set(float64(oldest.Time.Unix()), label...)
+	}
+}
+
+// worldSizes returns the size of each world, measuring them again if the
+// last measurement is too old.
+func (e *serverExporter) worldSizes() map[string]int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.sizes != nil && time.Since(e.measured) < sizeInterval {
+		return e.sizes
+	}
+
+	worlds, err := mcsmanager.Worlds(e.prefix)
+	if err != nil {
+		Log.Warnf("Unable to find worlds: %s\n", err)
+		return e.sizes
+	}
+
+	sizes := make(map[string]int64, len(worlds))
+	for _, world := range worlds {
+		size, err := mcsmanager.DirSize(filepath.Join(e.prefix, world))
+		if err != nil {
+			Log.Warnf("Unable to measure world '%s': %s\n", world, err)
+			continue
+		}
+		sizes[world] = size
+	}
+	e.sizes, e.measured = sizes, time.Now()
+
+	return sizes
+}
+
+// serverProcess finds the Java process of the server and reads its
+// resource usage.
+func serverProcess(name string) (metrics.Process, error) {
+	pid, err := tmux.PanePID(name)
+	if err != nil {
+		return metrics.Process{}, err
+	}
+
+	if pid, err = metrics.FindProcess(pid, "java"); err != nil {
+		return metrics.Process{}, err
+	}
+
+	return metrics.ReadProcess(pid)
+}
+
+// boolValue converts a bool to a metric value.
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/EbonJaeger/mcsmanager/provider"
+)
+
+func TestLastUpdate(t *testing.T) {
+	dir := t.TempDir()
+	if _, ok := lastUpdate(dir, "server.jar"); ok {
+		t.Fatal("expected no update time without a server jar")
+	}
+
+	// Forge updates only write the state file, and leave the jar alone
+	jarTime := time.Date(2021, 9, 1, 10, 0, 0, 0, time.UTC)
+	forgeTime := time.Date(2021, 9, 2, 10, 0, 0, 0, time.UTC)
+	for name, at := range map[string]time.Time{"server.jar": jarTime, provider.ForgeFile: forgeTime} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("error creating test file: %s\n", err)
+		}
+		if err := os.Chtimes(path, at, at); err != nil {
+			t.Fatalf("error setting file time: %s\n", err)
+		}
+	}
+
+	updated, ok := lastUpdate(dir, "server.jar")
+	if !ok || !updated.Equal(forgeTime) {
+		t.Fatalf("expected the update time to be %s, got %s", forgeTime, updated)
+	}
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/DataDrake/cli-ng/v2/cmd"
+)
+
+// ExpandLongFlags rewrites long flags that take a value, e.g. `--delay 5m`
+// or `--delay=5m`, to their short form. cli-ng only accepts a value after
+// a short flag, so long flags with values can't be parsed otherwise.
+//
+// The args are the full command line, starting with the program name and
+// the subcommand.
+func ExpandLongFlags(args []string, root *cmd.Root, subs ...*cmd.Sub) []string {
+	if len(args) < 2 {
+		return args
+	}
+
+	shorts := shortNames(root.Flags)
+	for _, sub := range subs {
+		if sub.Name == args[1] || sub.Alias == args[1] {
+			for long, short := range shortNames(sub.Flags) {
+				if _, ok := shorts[long]; !ok {
+					shorts[long] = short
+				}
+			}
+			break
+		}
+	}
+
+	expanded := make([]string, 0, len(args))
+	expanded = append(expanded, args[:2]...)
+	for i := 2; i < len(args); i++ {
+		arg := args[i]
+
+		// Everything after a bare "--" is an argument
+		if arg == "--" {
+			expanded = append(expanded, args[i:]...)
+			break
+		}
+
+		if !strings.HasPrefix(arg, "--") {
+			expanded = append(expanded, arg)
+			continue
+		}
+
+		parts := strings.SplitN(strings.TrimPrefix(arg, "--"), "=", 2)
+		short, ok := shorts[parts[0]]
+		if !ok {
+			expanded = append(expanded, arg)
+			continue
+		}
+
+		expanded = append(expanded, "-"+short)
+		if len(parts) == 2 {
+			expanded = append(expanded, parts[1])
+		}
+	}
+
+	return expanded
+}
+
+// shortNames maps the long names of the flags that take a value to their
+// short names.
+func shortNames(flags interface{}) map[string]string {
+	names := make(map[string]string)
+	if flags == nil {
+		return names
+	}
+
+	t := reflect.TypeOf(flags)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		long, short := field.Tag.Get("long"), field.Tag.Get("short")
+		if long != "" && short != "" && field.Type.Kind() != reflect.Bool {
+			names[long] = short
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/DataDrake/cli-ng/v2/cmd"
+)
+
+func TestExpandLongFlags(t *testing.T) {
+	root := &cmd.Root{Name: "mcsmanager", Flags: &GlobalFlags{}}
+	subs := []*cmd.Sub{&Restart, &Backup}
+
+	cases := map[string]struct {
+		args     []string
+		expected []string
+	}{
+		"value after the flag": {
+			[]string{"mcsmanager", "restart", "--delay", "5m"},
+			[]string{"mcsmanager", "restart", "-d", "5m"},
+		},
+		"value after an equals sign": {
+			[]string{"mcsmanager", "restart", "--delay=5m"},
+			[]string{"mcsmanager", "restart", "-d", "5m"},
+		},
+		"alias of the command": {
+			[]string{"mcsmanager", "re", "--delay=5m"},
+			[]string{"mcsmanager", "re", "-d", "5m"},
+		},
+		"global flag": {
+			[]string{"mcsmanager", "restart", "--server", "lobby", "--output=json"},
+			[]string{"mcsmanager", "restart", "-s", "lobby", "-O", "json"},
+		},
+		"bare double dash": {
+			[]string{"mcsmanager", "restart", "--", "--delay", "5m"},
+			[]string{"mcsmanager", "restart", "--", "--delay", "5m"},
+		},
+		"bool flags": {
+			[]string{"mcsmanager", "restart", "--backup", "--all"},
+			[]string{"mcsmanager", "restart", "--backup", "--all"},
+		},
+		"flag of another command": {
+			[]string{"mcsmanager", "backup", "--delay", "5m", "--online"},
+			[]string{"mcsmanager", "backup", "--delay", "5m", "--online"},
+		},
+		"unknown flags": {
+			[]string{"mcsmanager", "restart", "--forever=yes", "-d", "1m"},
+			[]string{"mcsmanager", "restart", "--forever=yes", "-d", "1m"},
+		},
+		"no command": {
+			[]string{"mcsmanager"},
+			[]string{"mcsmanager"},
+		},
+	}
+
+	for name, c := range cases {
+		if expanded := ExpandLongFlags(c.args, root, subs...); !reflect.DeepEqual(expanded, c.expected) {
+			t.Fatalf("%s: expected %q, got %q", name, c.expected, expanded)
+		}
+	}
+}
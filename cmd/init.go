@@ -78,6 +78,10 @@ func InitServer(root *cmd.Root, c *cmd.Sub) {
 	} else {
 		Log.Goodln("Server jar downloaded!")
 	}
+
+	if err := applyLauncher(conf, prefix, prov); err != nil {
+		Log.Fatalf("Error updating the server config: %s\n", err)
+	}
 }
 
 func isCommandAvailable(name string) bool {
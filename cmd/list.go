@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/DataDrake/cli-ng/v2/cmd"
+	"github.com/EbonJaeger/mcsmanager"
+	"github.com/EbonJaeger/mcsmanager/config"
+	"github.com/EbonJaeger/mcsmanager/tmux"
+)
+
+// List prints the servers in the inventory.
+var List = cmd.Sub{
+	Name:  "list",
+	Alias: "l",
+	Short: "List the servers in the inventory",
+	Run:   ListServers,
+}
+
+// serverResult is a server in the server listing.
+type serverResult struct {
+	Name    string `json:"name" yaml:"name"`
+	Path    string `json:"path" yaml:"path"`
+	State   string `json:"state" yaml:"state"`
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	Error   string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// ListServers prints the path, running state, and version of each server in
+// the inventory.
+func ListServers(root *cmd.Root, c *cmd.Sub) {
+	servers := inventoryServers()
+
+	results := make([]serverResult, 0, len(servers))
+	for _, server := range servers {
+		result := serverResult{Name: server.Name, Path: server.Path, State: stateStopped}
+
+		conf, err := config.Load(server.Path)
+		if err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+
+		if tmux.IsServerRunning(conf.MainSettings.ServerName) {
+			result.State = stateRunning
+		}
+		if version, err := mcsmanager.JarVersion(filepath.Join(server.Path, conf.MainSettings.ServerFile)); err == nil {
+			result.Version = version
+		}
+
+		results = append(results, result)
+	}
+
+	if writeResult(root, results) || len(results) == 0 {
+		return
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%sName\tPath\tRunning\tVersion\n", blue)
+	for _, result := range results {
+		running := "no"
+		if result.State == stateRunning {
+			running = "yes"
+		}
+
+		version := result.Version
+		if result.Error != "" {
+			version = fmt.Sprintf("%s%s%s", red, result.Error, reset)
+		} else if version == "" {
+			version = "unknown"
+		}
+
+		fmt.Fprintf(tw, "%s%s\t%s\t%s\t%s\n", reset, result.Name, result.Path, running, version)
+	}
+	tw.Flush()
+}
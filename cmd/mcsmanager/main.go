@@ -12,27 +12,45 @@ import (
 )
 
 func main() {
+	flags := &commands.GlobalFlags{}
 	root := &cmd.Root{
 		Name:  "mcsmanager",
 		Short: "Minecraft Server Manager",
-		Flags: &commands.GlobalFlags{},
+		Flags: flags,
 	}
 
 	// Initialize logging
-	logger := waterlog.New(os.Stdout, "", log2.Ltime)
+	logger := waterlog.New(commands.LogWriter{Flags: flags}, "", log2.Ltime)
 	logger.SetLevel(level.Info)
 	logger.SetFormat(format.Min)
 	commands.Log = logger
 
 	// Initialize subcommands
-	cmd.Register(&commands.Init)
-	cmd.Register(&commands.Exec)
-	cmd.Register(&commands.Start)
-	cmd.Register(&commands.Stop)
-	cmd.Register(&commands.Attach)
-	cmd.Register(&commands.Backup)
-	cmd.Register(&commands.Update)
-	cmd.Register(&commands.Status)
+	subs := []*cmd.Sub{
+		&commands.Init,
+		&commands.Exec,
+		&commands.Start,
+		&commands.Stop,
+		&commands.Restart,
+		&commands.Attach,
+		&commands.Console,
+		&commands.Backup,
+		&commands.Restore,
+		&commands.Prune,
+		&commands.Remote,
+		&commands.Update,
+		&commands.Status,
+		&commands.Daemon,
+		&commands.Supervise,
+		&commands.Exporter,
+		&commands.List,
+		&commands.Network,
+	}
+	for _, sub := range subs {
+		commands.ForAll(sub)
+		cmd.Register(sub)
+	}
 
+	os.Args = commands.ExpandLongFlags(os.Args, root, subs...)
 	root.Run()
 }
@@ -0,0 +1,316 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/DataDrake/cli-ng/v2/cmd"
+	"github.com/EbonJaeger/mcsmanager/config"
+	"github.com/EbonJaeger/mcsmanager/ping"
+	"github.com/EbonJaeger/mcsmanager/properties"
+	"github.com/EbonJaeger/mcsmanager/proxy"
+	"github.com/EbonJaeger/mcsmanager/tmux"
+)
+
+// Network manages a proxy and its backend servers together.
+var Network = cmd.Sub{
+	Name:  "network",
+	Alias: "w",
+	Short: "Start, stop, or check a proxy network from the inventory",
+	Args:  &NetworkArgs{},
+	Run:   ManageNetwork,
+}
+
+// NetworkArgs contains the command arguments for the network command.
+type NetworkArgs struct {
+	Args []string `zero:"true" desc:"Either \"start\", \"stop\", or \"status\", and the name of the network. The name can be left out if there is only one network"`
+}
+
+// backendTimeout is how long to wait for the backends to accept
+// connections before the proxy is started anyway.
+const backendTimeout = 3 * time.Minute
+
+// networkResult is the result of the network status command.
+type networkResult struct {
+	Name     string          `json:"name" yaml:"name"`
+	Type     string          `json:"type,omitempty" yaml:"type,omitempty"`
+	Proxy    memberResult    `json:"proxy" yaml:"proxy"`
+	Backends []*memberResult `json:"backends" yaml:"backends"`
+}
+
+// memberResult is a server in a network.
+type memberResult struct {
+	Server        string `json:"server" yaml:"server"`
+	State         string `json:"state" yaml:"state"`
+	Address       string `json:"address,omitempty" yaml:"address,omitempty"`
+	PlayersOnline int    `json:"players_online" yaml:"players_online"`
+	Registered    string `json:"registered,omitempty" yaml:"registered,omitempty"`
+	Error         string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// ManageNetwork starts, stops, or checks the servers of a network. The
+// backends are started before the proxy, so players always have somewhere
+// to go, and the proxy is stopped first so players aren't sent to a backend
+// that is shutting down.
+func ManageNetwork(root *cmd.Root, c *cmd.Sub) {
+	args := c.Args.(*NetworkArgs).Args
+	if len(args) == 0 || len(args) > 2 {
+		Log.Fatalln("Usage: mcsmanager network <start|stop|status> [network]")
+	}
+
+	inv, err := config.LoadInventory()
+	if err != nil {
+		Log.Fatalf("Error loading the server inventory: %s\n", err)
+	}
+
+	networks := inv.ListNetworks()
+	switch {
+	case len(args) == 2:
+		network, err := inv.FindNetwork(args[1])
+		if err != nil {
+			Log.Fatalf("Unable to find network: %s\n", err)
+		}
+		networks = []*config.Network{network}
+	case root.Flags.(*GlobalFlags).All:
+		// Every network in the inventory
+	case len(networks) == 0:
+		path, _ := config.InventoryPath()
+		Log.Fatalf("There are no networks in the inventory at '%s'\n", path)
+	case len(networks) > 1:
+		Log.Fatalln("There is more than one network in the inventory, so the name of the network is needed")
+	}
+
+	switch args[0] {
+	case "start":
+		for _, network := range networks {
+			startNetwork(inv, network)
+		}
+	case "stop":
+		for _, network := range networks {
+			stopNetwork(inv, network)
+		}
+	case "status":
+		results := make([]networkResult, 0, len(networks))
+		for _, network := range networks {
+			results = append(results, checkNetwork(inv, network))
+		}
+
+		if !writeResult(root, results) {
+			for _, result := range results {
+				printNetwork(result)
+			}
+		}
+
+		// Exit with an error if any backend isn't reachable through the proxy
+		for _, result := range results {
+			for _, backend := range result.Backends {
+				if backend.Registered == "" {
+					os.Exit(1)
+				}
+			}
+		}
+	default:
+		Log.Fatalf("Unknown network command '%s'\n", args[0])
+	}
+}
+
+// startNetwork starts each backend of a network, waits for them to accept
+// connections, and then starts the proxy.
+func startNetwork(inv *config.Inventory, network *config.Network) {
+	Log.Infof("Starting network '%s'...\n", network.Name)
+
+	for _, name := range network.Backends {
+		server := inv.Servers[name]
+		Log.Infof("Starting backend '%s'...\n", name)
+		if err := runSelf(server.Path, "start"); err != nil {
+			Log.Errorf("Unable to start backend '%s': %s\n", name, err)
+		}
+	}
+
+	for _, name := range network.Backends {
+		if err := waitForServer(inv.Servers[name].Path); err != nil {
+			Log.Warnf("Backend '%s' isn't accepting connections: %s\n", name, err)
+		}
+	}
+
+	Log.Infof("Starting proxy '%s'...\n", network.Proxy)
+	if err := runSelf(inv.Servers[network.Proxy].Path, "start"); err != nil {
+		Log.Fatalf("Unable to start proxy '%s': %s\n", network.Proxy, err)
+	}
+
+	Log.Goodf("Network '%s' started!\n", network.Name)
+}
+
+// stopNetwork stops the proxy of a network, and then its backends in the
+// reverse order that they were started in.
+func stopNetwork(inv *config.Inventory, network *config.Network) {
+	Log.Infof("Stopping network '%s'...\n", network.Name)
+
+	Log.Infof("Stopping proxy '%s'...\n", network.Proxy)
+	if err := runSelf(inv.Servers[network.Proxy].Path, "stop"); err != nil {
+		Log.Errorf("Unable to stop proxy '%s': %s\n", network.Proxy, err)
+	}
+
+	for i := len(network.Backends) - 1; i >= 0; i-- {
+		name := network.Backends[i]
+		Log.Infof("Stopping backend '%s'...\n", name)
+		if err := runSelf(inv.Servers[name].Path, "stop"); err != nil {
+			Log.Errorf("Unable to stop backend '%s': %s\n", name, err)
+		}
+	}
+
+	Log.Goodf("Network '%s' stopped!\n", network.Name)
+}
+
+// waitForServer waits until a server answers pings, or its window closes.
+func waitForServer(prefix string) error {
+	conf, err := config.Load(prefix)
+	if err != nil {
+		return err
+	}
+	props, err := properties.Load(prefix)
+	if err != nil {
+		return err
+	}
+	address := pingAddress(backendAddress(props))
+
+	deadline := time.Now().Add(backendTimeout)
+	for {
+		if !tmux.IsServerRunning(conf.MainSettings.ServerName) {
+			return fmt.Errorf("the server isn't running")
+		}
+		if _, err = ping.Ping(address, pingTimeout); err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
+
+// checkNetwork checks that the proxy and backends of a network are running,
+// and that each backend is registered in the proxy config.
+func checkNetwork(inv *config.Inventory, network *config.Network) networkResult {
+	result := networkResult{
+		Name:     network.Name,
+		Proxy:    memberResult{Server: network.Proxy, State: stateStopped},
+		Backends: make([]*memberResult, 0, len(network.Backends)),
+	}
+
+	prefix := inv.Servers[network.Proxy].Path
+	proxyConf, err := proxy.Load(prefix)
+	if err != nil {
+		result.Proxy.Error = fmt.Sprintf("unable to read proxy config: %s", err)
+	} else {
+		result.Type = proxyConf.Type
+		result.Proxy.Address = proxyConf.Bind
+	}
+	checkMember(&result.Proxy, prefix)
+
+	for _, name := range network.Backends {
+		backend := &memberResult{Server: name, State: stateStopped}
+		result.Backends = append(result.Backends, backend)
+
+		prefix := inv.Servers[name].Path
+		props, err := properties.Load(prefix)
+		if err != nil {
+			backend.Error = fmt.Sprintf("unable to read server.properties: %s", err)
+			continue
+		}
+		backend.Address = backendAddress(props)
+
+		if proxyConf != nil {
+			backend.Registered, _ = proxyConf.Find(backend.Address)
+		}
+		checkMember(backend, prefix)
+	}
+
+	return result
+}
+
+// checkMember fills in the state and player count of a server in a network.
+func checkMember(member *memberResult, prefix string) {
+	conf, err := config.Load(prefix)
+	if err != nil {
+		if member.Error == "" {
+			member.Error = fmt.Sprintf("unable to load server config: %s", err)
+		}
+		return
+	}
+
+	if !tmux.IsServerRunning(conf.MainSettings.ServerName) {
+		return
+	}
+
+	member.State = stateStarting
+	if member.Address == "" {
+		return
+	}
+	if status, err := ping.Ping(pingAddress(member.Address), pingTimeout); err == nil {
+		member.State = stateRunning
+		member.PlayersOnline = status.Players.Online
+	}
+}
+
+// backendAddress returns the address that a server listens on, as set in
+// its server.properties. The host is empty if it listens on every interface.
+func backendAddress(props properties.Map) string {
+	return net.JoinHostPort(props.Get("server-ip", ""), props.Get("server-port", strconv.Itoa(ping.DefaultPort)))
+}
+
+// pingAddress turns an address that a server listens on into one that we
+// can connect to.
+func pingAddress(address string) string {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+
+	return net.JoinHostPort(host, port)
+}
+
+// printNetwork prints the state of a network as a table.
+func printNetwork(result networkResult) {
+	kind := result.Type
+	if kind == "" {
+		kind = "unknown proxy"
+	}
+	fmt.Printf("%s========== Network '%s' (%s) ==========%s\n", blue, result.Name, kind, reset)
+
+	// Colors are kept out of the cells, so they don't throw off the columns
+	fmt.Print(blue)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Server\tRole\tState\tAddress\tPlayers\tRegistered As%s\n", reset)
+
+	// printMember prints a row for a server, with errors in red after it
+	printMember := func(member *memberResult, role, registered string) {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\n", member.Server, role, member.State, member.Address, member.PlayersOnline, registered)
+		if member.Error != "" {
+			fmt.Fprintf(tw, "\t%s%s%s\n", red, member.Error, reset)
+		}
+	}
+
+	printMember(&result.Proxy, "proxy", "-")
+	for _, backend := range result.Backends {
+		registered := backend.Registered
+		if registered == "" {
+			registered = "no"
+		}
+		printMember(backend, "backend", registered)
+	}
+	tw.Flush()
+
+	for _, backend := range result.Backends {
+		if backend.Registered == "" && backend.Error == "" {
+			Log.Warnf("Backend '%s' at %s isn't registered in the proxy config\n", backend.Server, backend.Address)
+		}
+	}
+}
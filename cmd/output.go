@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/DataDrake/cli-ng/v2/cmd"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats for the results of commands.
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+	OutputYAML = "yaml"
+)
+
+// IsStructured checks if results should be printed as JSON or YAML
+// instead of text.
+func (f GlobalFlags) IsStructured() bool {
+	return f.Output != "" && f.Output != OutputText
+}
+
+// LogWriter writes log messages to stdout, or to stderr when the results
+// are printed as JSON or YAML so they can be parsed by other programs.
+type LogWriter struct {
+	Flags *GlobalFlags
+}
+
+// Write writes a log message to stdout or stderr.
+func (w LogWriter) Write(p []byte) (int, error) {
+	if w.Flags.IsStructured() {
+		return os.Stderr.Write(p)
+	}
+
+	return os.Stdout.Write(p)
+}
+
+// writeResult prints the result of a command as JSON or YAML, depending on
+// the output flag. Returns false if the result should be printed as text,
+// which is left up to the command.
+func writeResult(root *cmd.Root, result interface{}) bool {
+	switch format := root.Flags.(*GlobalFlags).Output; format {
+	case "", OutputText:
+		return false
+	case OutputJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			Log.Fatalf("Error writing JSON: %s\n", err)
+		}
+	case OutputYAML:
+		enc := yaml.NewEncoder(os.Stdout)
+		enc.SetIndent(2)
+		if err := enc.Encode(result); err != nil {
+			Log.Fatalf("Error writing YAML: %s\n", err)
+		}
+		enc.Close()
+	default:
+		Log.Fatalf("Unknown output format '%s', must be text, json, or yaml\n", format)
+	}
+
+	return true
+}
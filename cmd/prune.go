@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/DataDrake/cli-ng/v2/cmd"
+	"github.com/EbonJaeger/mcsmanager/config"
+)
+
+// Prune removes old backups.
+var Prune = cmd.Sub{
+	Name:  "prune",
+	Alias: "p",
+	Short: "Remove backups that are too old or over the backup limit",
+	Run:   PruneBackups,
+}
+
+// PruneBackups removes backups according to the backup settings in the config.
+// For a backup repository, any chunks that are no longer used by a snapshot
+// are removed as well. Archives of backup sets are pruned using the limits
+// of their set, and archives of single worlds using the global limits.
+func PruneBackups(root *cmd.Root, c *cmd.Sub) {
+	prefix, err := root.Flags.(*GlobalFlags).GetPathPrefix()
+	if err != nil {
+		Log.Fatalf("Error getting the working directory: %s\n", err)
+	}
+
+	conf, err := config.Load(prefix)
+	if err != nil {
+		Log.Fatalf("Error loading server config: %s\n", err)
+	}
+
+	backupDir := getBackupDir(conf, prefix)
+	if _, err := os.Stat(backupDir); os.IsNotExist(err) {
+		Log.Infoln("There are no backups to prune")
+		return
+	}
+
+	Log.Infoln("Pruning backups...")
+
+	switch conf.BackupSettings.Backend {
+	case config.BackendRepository:
+		pruneRepository(conf, backupDir)
+	default:
+		pruneArchives(backupDir, conf.BackupSettings.MaxBackups, conf.BackupSettings.MaxAge, false)
+		pruneSelections(conf, backupDir)
+	}
+
+	Log.Goodln("Backups pruned!")
+}
+
+// pruneSelections removes old archives of backup sets and single worlds.
+func pruneSelections(conf config.Root, backupDir string) {
+	for _, set := range conf.BackupSettings.Sets {
+		if _, ok := findSet(conf, set.Name); !ok {
+			continue
+		}
+
+		dir := filepath.Join(backupDir, setsDir, set.Name)
+		if _, err := os.Stat(dir); err == nil {
+			maxBackups, maxAge := conf.BackupSettings.SetLimits(set)
+			pruneArchives(dir, maxBackups, maxAge, false)
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Join(backupDir, worldsDir))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			Log.Fatalf("Unable to read world backups: %s\n", err)
+		}
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			dir := filepath.Join(backupDir, worldsDir, entry.Name())
+			pruneArchives(dir, conf.BackupSettings.MaxBackups, conf.BackupSettings.MaxAge, false)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DataDrake/cli-ng/v2/cmd"
+	"github.com/DataDrake/waterlog"
+	"github.com/EbonJaeger/mcsmanager"
+	"github.com/EbonJaeger/mcsmanager/config"
+)
+
+// createArchives creates empty archives with timestamps a day apart.
+func createArchives(t *testing.T, dir string, count int) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("error creating backup dir: %s\n", err)
+	}
+	for day := 1; day <= count; day++ {
+		name := filepath.Join(dir, fmt.Sprintf("2021-09-%02dT10:00:00-0500.tar.gz", day))
+		if err := os.WriteFile(name, nil, 0644); err != nil {
+			t.Fatalf("error creating archive: %s\n", err)
+		}
+	}
+}
+
+func TestPruneKeepsLimit(t *testing.T) {
+	Log = waterlog.New(io.Discard, "", 0)
+
+	dir := t.TempDir()
+	conf := config.Default()
+	conf.BackupSettings.MaxBackups = 3
+	conf.BackupSettings.MaxAge = -1
+	conf.BackupSettings.Sets = []config.BackupSet{{Name: "plugins", MaxBackups: 2}}
+	if err := conf.Save(dir); err != nil {
+		t.Fatalf("error saving config: %s\n", err)
+	}
+
+	backupDir := getBackupDir(conf, dir)
+	createArchives(t, backupDir, 5)
+	createArchives(t, filepath.Join(backupDir, setsDir, "plugins"), 5)
+	createArchives(t, filepath.Join(backupDir, worldsDir, "world"), 5)
+
+	root := &cmd.Root{Flags: &GlobalFlags{Path: dir}}
+	PruneBackups(root, &Prune)
+
+	cases := map[string]int{
+		backupDir: 3,
+		filepath.Join(backupDir, setsDir, "plugins"): 2,
+		filepath.Join(backupDir, worldsDir, "world"): 3,
+	}
+	for path, expected := range cases {
+		backups, err := mcsmanager.ListBackups(path)
+		if err != nil {
+			t.Fatalf("error listing backups: %s\n", err)
+		}
+		if len(backups) != expected {
+			t.Fatalf("expected %d backups in %s, got %d", expected, path, len(backups))
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/DataDrake/cli-ng/v2/cmd"
+	"github.com/EbonJaeger/mcsmanager"
+	"github.com/EbonJaeger/mcsmanager/config"
+	"github.com/EbonJaeger/mcsmanager/target"
+)
+
+// Remote manages the backups stored on backup targets.
+var Remote = cmd.Sub{
+	Name:  "remote",
+	Alias: "m",
+	Short: "List or download backups from other backup targets",
+	Args:  &RemoteArgs{},
+	Flags: &RemoteFlags{},
+	Run:   RemoteBackups,
+}
+
+// RemoteArgs contains the command arguments for the remote command.
+type RemoteArgs struct {
+	Args []string `zero:"true" desc:"Either \"list\", or \"download <backup>\" to copy a backup into the backup directory"`
+}
+
+// RemoteFlags holds the flags for the remote command.
+type RemoteFlags struct {
+	Target string `short:"t" long:"target" desc:"Name of the backup target to use. Defaults to the first target"`
+}
+
+// RemoteBackups lists the backups on every backup target, or downloads
+// a backup from a target into the backup directory so it can be restored.
+func RemoteBackups(root *cmd.Root, c *cmd.Sub) {
+	args := c.Args.(*RemoteArgs).Args
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+
+	prefix, err := root.Flags.(*GlobalFlags).GetPathPrefix()
+	if err != nil {
+		Log.Fatalf("Error getting the working directory: %s\n", err)
+	}
+
+	conf, err := config.Load(prefix)
+	if err != nil {
+		Log.Fatalf("Error loading server config: %s\n", err)
+	}
+
+	if len(conf.BackupSettings.Targets) == 0 {
+		Log.Warnln("No backup targets are configured!")
+		return
+	}
+
+	switch {
+	case args[0] == "list" && len(args) == 1:
+		results := make([]targetResult, 0, len(conf.BackupSettings.Targets))
+		for _, settings := range conf.BackupSettings.Targets {
+			if name := c.Flags.(*RemoteFlags).Target; name != "" && settings.Name != name {
+				continue
+			}
+
+			t, err := target.MatchTarget(settings)
+			if err != nil {
+				Log.Fatalf("Invalid backup target: %s\n", err)
+			}
+
+			backups, err := target.ListBackups(t)
+			if err != nil {
+				Log.Fatalf("Unable to list backups on '%s': %s\n", settings.Name, err)
+			}
+
+			if root.Flags.(*GlobalFlags).IsStructured() {
+				results = append(results, targetResult{Target: settings.Name, Backups: newBackupResults(backups)})
+				continue
+			}
+
+			Log.Infof("Backups on '%s':\n", settings.Name)
+			printBackups(backups)
+		}
+		writeResult(root, results)
+	case args[0] == "download" && len(args) == 2:
+		settings, err := findTarget(conf, c.Flags.(*RemoteFlags).Target)
+		if err != nil {
+			Log.Fatalf("Unable to find backup target: %s\n", err)
+		}
+
+		t, err := target.MatchTarget(settings)
+		if err != nil {
+			Log.Fatalf("Invalid backup target: %s\n", err)
+		}
+
+		backups, err := target.ListBackups(t)
+		if err != nil {
+			Log.Fatalf("Unable to list backups on '%s': %s\n", settings.Name, err)
+		}
+
+		backup, err := mcsmanager.FindBackup(backups, args[1])
+		if err != nil {
+			Log.Fatalf("Unable to find backup: %s\n", err)
+		}
+
+		backupDir := getBackupDir(conf, prefix)
+		if err = os.MkdirAll(backupDir, 0755); err != nil {
+			Log.Fatalf("Unable to create backups directory: %s\n", err)
+		}
+
+		Log.Infof("Downloading '%s' from '%s'...\n", backup.Name, settings.Name)
+		path := filepath.Join(backupDir, backup.Name)
+		if err = t.Download(backup.Name, path); err != nil {
+			os.Remove(path)
+			Log.Fatalf("Error downloading backup: %s\n", err)
+		}
+
+		// Older backups might not have a manifest
+		manifest := mcsmanager.ManifestPath(backup.Name)
+		if err = t.Download(manifest, filepath.Join(backupDir, manifest)); err != nil {
+			os.Remove(filepath.Join(backupDir, manifest))
+			Log.Warnf("Unable to download the manifest for '%s': %s\n", backup.Name, err)
+		}
+
+		Log.Goodf("Backup downloaded! Use 'mcsmanager restore %s' to restore it.\n", mcsmanager.TrimArchiveExt(backup.Name))
+	default:
+		Log.Errorln("Unknown arguments!")
+		Log.Errorln("")
+		Log.Errorln("USAGE:")
+		Log.Errorf("\tmcsmanager %s list\n", c.Name)
+		Log.Errorln("OR")
+		Log.Errorf("\tmcsmanager %s download <backup>\n", c.Name)
+		os.Exit(1)
+	}
+}
+
+// uploadBackup copies a backup archive and its manifest to every backup
+// target, and then prunes the backups on each target. It returns false
+// if the backup could not be copied to every target.
+func uploadBackup(conf config.Root, archive string) bool {
+	ok := true
+	for _, settings := range conf.BackupSettings.Targets {
+		t, err := target.MatchTarget(settings)
+		if err != nil {
+			Log.Errorf("Invalid backup target: %s\n", err)
+			ok = false
+			continue
+		}
+
+		Log.Infof("Copying backup to '%s'...\n", settings.Name)
+		if err = t.Upload(archive); err != nil {
+			Log.Errorf("Unable to copy backup to '%s': %s\n", settings.Name, err)
+			ok = false
+			continue
+		}
+		if err = t.Upload(mcsmanager.ManifestPath(archive)); err != nil {
+			Log.Errorf("Unable to copy backup manifest to '%s': %s\n", settings.Name, err)
+			ok = false
+			continue
+		}
+
+		pruned, err := target.Prune(t, conf.BackupSettings.MaxBackups, conf.BackupSettings.MaxAge)
+		if err != nil {
+			Log.Errorf("Unable to remove old backups from '%s': %s\n", settings.Name, err)
+			ok = false
+			continue
+		}
+		if pruned > 0 {
+			Log.Infof("Removed %d archive(s) from '%s'.\n", pruned, settings.Name)
+		}
+
+		Log.Goodf("Backup copied to '%s'\n", settings.Name)
+	}
+
+	return ok
+}
+
+// findTarget returns the settings for the backup target with the given name,
+// or the first target if no name is given.
+func findTarget(conf config.Root, name string) (config.TargetSettings, error) {
+	targets := conf.BackupSettings.Targets
+	if name == "" {
+		return targets[0], nil
+	}
+
+	for _, settings := range targets {
+		if settings.Name == name {
+			return settings, nil
+		}
+	}
+
+	return config.TargetSettings{}, fmt.Errorf("no backup target named '%s'", name)
+}
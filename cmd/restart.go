@@ -0,0 +1,299 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/DataDrake/cli-ng/v2/cmd"
+	"github.com/EbonJaeger/mcsmanager/config"
+	"github.com/EbonJaeger/mcsmanager/tmux"
+)
+
+// defaultRestartMessage is the warning sent before a restart if the
+// config doesn't have one.
+const defaultRestartMessage = "Server restarting in {time}!"
+
+// RestartFlags holds the flags for the restart command.
+type RestartFlags struct {
+	Delay  string `short:"d" long:"delay" arg:"true" desc:"Count down for this long before restarting, e.g. \"5m\""`
+	Backup bool   `short:"b" long:"backup" desc:"Back up the server while it is stopped"`
+	Update bool   `short:"u" long:"update" desc:"Update the server jar while it is stopped, using the given URL or provider and version"`
+}
+
+// RestartArgs contains the command arguments for the restart command.
+type RestartArgs struct {
+	Args []string `zero:"true" desc:"URL to the server jar, or a provider and version, to update to with '--update'"`
+}
+
+// Restart stops the Minecraft server and starts it again.
+var Restart = cmd.Sub{
+	Name:  "restart",
+	Alias: "re",
+	Short: "Restart the Minecraft server, warning players with a countdown first",
+	Args:  &RestartArgs{},
+	Flags: &RestartFlags{},
+	Run:   RestartServer,
+}
+
+// countdown is a list of warnings that are sent to the players before
+// the server restarts.
+type countdown struct {
+	Warnings []time.Duration
+	Message  string
+	Title    bool
+}
+
+// RestartServer counts down, stops the server, optionally backs it up or
+// updates it, and then starts it again. Interrupting the countdown cancels
+// the restart.
+func RestartServer(root *cmd.Root, c *cmd.Sub) {
+	flags := c.Flags.(*RestartFlags)
+	args := c.Args.(*RestartArgs).Args
+
+	if flags.Update != (len(args) > 0) || len(args) > 2 {
+		Log.Errorln("Incorrect number of args!")
+		Log.Errorln("")
+		Log.Errorln("USAGE:")
+		Log.Errorf("\tmcsmanager %s [--delay <duration>] [--backup]\n", c.Name)
+		Log.Errorln("OR")
+		Log.Errorf("\tmcsmanager %s [--delay <duration>] [--backup] --update <url>\n", c.Name)
+		Log.Errorln("OR")
+		Log.Errorf("\tmcsmanager %s [--delay <duration>] [--backup] --update <provider> <version>\n", c.Name)
+		os.Exit(1)
+	}
+
+	prefix, err := root.Flags.(*GlobalFlags).GetPathPrefix()
+	if err != nil {
+		Log.Fatalf("Error getting the working directory: %s\n", err)
+	}
+
+	conf, err := config.Load(prefix)
+	if err != nil {
+		Log.Fatalf("Error loading server config: %s\n", err)
+	}
+
+	delay := time.Duration(0)
+	if flags.Delay != "" {
+		if delay, err = time.ParseDuration(flags.Delay); err != nil || delay < 0 {
+			Log.Fatalf("Invalid delay: '%s'\n", flags.Delay)
+		}
+	}
+
+	counter, err := newCountdown(conf, nil, "")
+	if err != nil {
+		Log.Fatalf("Invalid restart settings: %s\n", err)
+	}
+
+	// Only use the warnings that fit in our delay, and always warn
+	// the players when the countdown starts
+	warnings := make([]time.Duration, 0, len(counter.Warnings)+1)
+	if delay > 0 {
+		warnings = append(warnings, delay)
+	}
+	for _, warning := range counter.Warnings {
+		if warning < delay {
+			warnings = append(warnings, warning)
+		}
+	}
+	counter.Warnings = warnings
+
+	name := conf.MainSettings.ServerName
+	if !tmux.IsServerRunning(name) {
+		Log.Warnln("The Minecraft server is not running!")
+		return
+	}
+
+	// Things to do while the server is stopped
+	between := make([][]string, 0)
+	if flags.Backup {
+		between = append(between, []string{"backup"})
+	}
+	if flags.Update {
+		between = append(between, append([]string{"update"}, args...))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err = restartServer(ctx, prefix, conf, time.Now().Add(delay), counter, between...); err != nil {
+		if ctx.Err() != nil {
+			announce(prefix, name, "Server restart cancelled", counter.Title)
+			Log.Warnln("Restart cancelled")
+			return
+		}
+		Log.Fatalf("Error restarting the server: %s\n", err)
+	}
+
+	Log.Goodln("Server restarted!")
+}
+
+// newCountdown builds a countdown from the restart settings in the config.
+// The given warnings and message are used instead, if they're set.
+func newCountdown(conf config.Root, warnings []string, message string) (counter countdown, err error) {
+	if len(warnings) == 0 {
+		warnings = conf.RestartSettings.Warnings
+	}
+	if counter.Warnings, err = parseWarnings(warnings); err != nil {
+		return
+	}
+
+	counter.Message = message
+	if counter.Message == "" {
+		counter.Message = conf.RestartSettings.Message
+	}
+	if counter.Message == "" {
+		counter.Message = defaultRestartMessage
+	}
+	counter.Title = conf.RestartSettings.Title
+
+	return
+}
+
+// restartServer warns the players about a restart, and then stops the server
+// at the given time. While the server is stopped, each of the given
+// mcsmanager commands are run, and then the server is started again. If a
+// command fails, the server is still started.
+//
+// If the server isn't running, nothing happens.
+func restartServer(ctx context.Context, prefix string, conf config.Root, at time.Time, counter countdown, between ...[]string) error {
+	name := conf.MainSettings.ServerName
+	if !tmux.IsServerRunning(name) {
+		Log.Infoln("Server is not running, skipping restart")
+		return nil
+	}
+
+	Log.Infof("Restarting the server at %s\n", at.Format(time.Kitchen))
+	if err := counter.Run(ctx, prefix, name, at); err != nil {
+		return err
+	}
+
+	Log.Infoln("Stopping the server...")
+	stopServer(prefix, name)
+
+	var failed error
+	for _, args := range between {
+		if err := runSelf(prefix, args...); err != nil {
+			Log.Errorf("Error running '%s': %s\n", strings.Join(args, " "), err)
+			failed = fmt.Errorf("'%s' failed: %s", args[0], err)
+		}
+	}
+
+	if err := pruneLogs(conf, prefix); err != nil {
+		Log.Warnf("Unable to remove old logs: %s\n", err)
+	}
+
+	Log.Infoln("Starting the server...")
+	if err := launchServer(conf, prefix); err != nil {
+		return fmt.Errorf("unable to start the server: %s", err)
+	}
+
+	return failed
+}
+
+// Run sends each warning to the players when there is that much time left
+// until the given time, and returns at that time. Warnings that are already
+// too late are skipped.
+func (c countdown) Run(ctx context.Context, prefix, name string, at time.Time) error {
+	for _, warning := range c.Warnings {
+		// Allow a little slack so the first warning isn't skipped
+		left := time.Until(at)
+		if left+time.Second < warning {
+			continue
+		}
+
+		if err := sleep(ctx, left-warning); err != nil {
+			return err
+		}
+
+		announce(prefix, name, strings.ReplaceAll(c.Message, "{time}", formatCountdown(warning)), c.Title)
+	}
+
+	return sleep(ctx, time.Until(at))
+}
+
+// announce sends a message to all players in the chat, and optionally
+// as a title on their screens.
+func announce(prefix, name, message string, title bool) {
+	if _, err := sendCommand(prefix, name, "say "+message); err != nil {
+		Log.Warnf("Unable to send message to the players: %s\n", err)
+	}
+
+	if title {
+		text, _ := json.Marshal(map[string]string{"text": message})
+		if _, err := sendCommand(prefix, name, "title @a title "+string(text)); err != nil {
+			Log.Warnf("Unable to send title to the players: %s\n", err)
+		}
+	}
+}
+
+// parseWarnings parses a list of durations, sorted so the longest is first.
+func parseWarnings(raw []string) ([]time.Duration, error) {
+	warnings := make([]time.Duration, 0, len(raw))
+	for _, s := range raw {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("bad restart warning '%s'", s)
+		}
+		warnings = append(warnings, d)
+	}
+
+	sort.Slice(warnings, func(i, j int) bool {
+		return warnings[i] > warnings[j]
+	})
+
+	return warnings, nil
+}
+
+// formatCountdown formats a duration for players, e.g. "5 minutes"
+// or "1 minute 30 seconds".
+func formatCountdown(d time.Duration) string {
+	units := []struct {
+		name string
+		size time.Duration
+	}{
+		{"hour", time.Hour},
+		{"minute", time.Minute},
+		{"second", time.Second},
+	}
+
+	parts := make([]string, 0, len(units))
+	for _, unit := range units {
+		n := int(d / unit.size)
+		d -= time.Duration(n) * unit.size
+		if n == 1 {
+			parts = append(parts, "1 "+unit.name)
+		} else if n > 1 {
+			parts = append(parts, fmt.Sprintf("%d %ss", n, unit.name))
+		}
+	}
+
+	if len(parts) == 0 {
+		return "now"
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// sleep waits for the given duration, or until the context is cancelled.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d < 0 {
+		d = 0
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/DataDrake/cli-ng/v2/cmd"
+	"github.com/EbonJaeger/mcsmanager"
+	"github.com/EbonJaeger/mcsmanager/config"
+	"github.com/EbonJaeger/mcsmanager/tmux"
+	"github.com/dustin/go-humanize"
+)
+
+// Restore replaces the server files with the contents of a backup archive.
+var Restore = cmd.Sub{
+	Name:  "restore",
+	Alias: "r",
+	Short: "Restore the server files from a backup archive",
+	Args:  &RestoreArgs{},
+	Flags: &RestoreFlags{},
+	Run:   RestoreServer,
+}
+
+// RestoreArgs contains the command arguments for the restore command.
+type RestoreArgs struct {
+	Backup []string `zero:"true" desc:"The timestamp of the backup to restore, or \"latest\". Lists all backups if omitted"`
+}
+
+// RestoreFlags holds the flags for the restore command.
+type RestoreFlags struct {
+	Yes bool `short:"y" long:"yes" desc:"Don't ask for confirmation before restoring"`
+}
+
+// RestoreServer lists the available backups, or unpacks one of them
+// and replaces the server files with its contents.
+func RestoreServer(root *cmd.Root, c *cmd.Sub) {
+	args := c.Args.(*RestoreArgs).Backup
+	if len(args) > 1 {
+		Log.Fatalln("Only one backup can be restored at a time")
+	}
+
+	prefix, err := root.Flags.(*GlobalFlags).GetPathPrefix()
+	if err != nil {
+		Log.Fatalf("Error getting the working directory: %s\n", err)
+	}
+
+	conf, err := config.Load(prefix)
+	if err != nil {
+		Log.Fatalf("Error loading server config: %s\n", err)
+	}
+
+	backupDir := getBackupDir(conf, prefix)
+
+	// Snapshots in a backup repository are restored the same way as archives
+	repo, backups := loadBackups(conf, backupDir)
+
+	// Print the backups if we weren't told which one to restore
+	if len(args) == 0 {
+		if !writeResult(root, newBackupResults(backups)) {
+			printBackups(backups)
+		}
+		return
+	}
+
+	backup, err := mcsmanager.FindBackup(backups, args[0])
+	if err != nil {
+		Log.Fatalf("Unable to find backup: %s\n", err)
+	}
+
+	// Check if the server is currently running
+	if tmux.IsServerRunning(conf.MainSettings.ServerName) {
+		Log.Warnln("Please stop the server before trying to restore a backup!")
+		return
+	}
+
+	if !c.Flags.(*RestoreFlags).Yes {
+		Log.Warnf("All server files will be replaced with the contents of '%s'!\n", backup.Name)
+		Log.Print("     Continue? [y/N] ")
+
+		reader := bufio.NewReader(os.Stdin)
+		char, _, err := reader.ReadRune()
+		if err != nil {
+			Log.Fatalln("Error while reading input:", err)
+		}
+
+		if char != 'y' && char != 'Y' {
+			Log.Goodln("Exiting!")
+			return
+		}
+	}
+
+	// Extract the backup next to the server files first, so a bad archive
+	// doesn't leave us with half of a server.
+	staging, err := os.MkdirTemp(prefix, ".restore-")
+	if err != nil {
+		Log.Fatalf("Unable to create staging directory: %s\n", err)
+	}
+
+	Log.Infof("Extracting '%s'...\n", backup.Name)
+	start := time.Now()
+	if repo != nil {
+		err = repo.RestoreSnapshot(backup.Name, staging)
+	} else {
+		err = mcsmanager.Extract(backup.Path, staging)
+	}
+	if err != nil {
+		os.RemoveAll(staging)
+		Log.Fatalf("Error extracting backup: %s\n", err)
+	}
+
+	// Keep the backups and anything that was never archived
+	exclusions := getExclusions(conf, prefix)
+
+	Log.Infoln("Replacing server files...")
+	if err = mcsmanager.Swap(staging, prefix, exclusions...); err != nil {
+		os.RemoveAll(staging)
+		Log.Fatalf("Error replacing server files: %s\n", err)
+	}
+
+	Log.Goodf("Server restored from '%s' in %v\n", backup.Name, time.Since(start))
+}
+
+// printBackups writes a table of backups to stdout.
+func printBackups(backups []mcsmanager.Backup) {
+	if len(backups) == 0 {
+		Log.Infoln("There are no backups to restore")
+		return
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%sTimestamp\tSize\tAge\n", blue)
+	for _, backup := range backups {
+		fmt.Fprintf(tw, "%s%s\t%s\t%s\n", reset, mcsmanager.TrimArchiveExt(backup.Name), humanize.Bytes(uint64(backup.Size)), humanize.Time(backup.Time))
+	}
+	tw.Flush()
+}
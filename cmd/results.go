@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/EbonJaeger/mcsmanager"
+	"github.com/EbonJaeger/mcsmanager/ping"
+	"github.com/EbonJaeger/mcsmanager/properties"
+	"github.com/EbonJaeger/mcsmanager/query"
+)
+
+// Server states in the status result.
+const (
+	stateRunning  = "running"
+	stateStarting = "starting"
+	stateStopped  = "stopped"
+)
+
+// statusResult is the result of the status command.
+type statusResult struct {
+	Name       string            `json:"name" yaml:"name"`
+	State      string            `json:"state" yaml:"state"`
+	MaxMemory  string            `json:"max_memory" yaml:"max_memory"`
+	Properties map[string]string `json:"properties" yaml:"properties"`
+	Ping       *pingResult       `json:"ping,omitempty" yaml:"ping,omitempty"`
+	Query      *queryResult      `json:"query,omitempty" yaml:"query,omitempty"`
+}
+
+// pingResult is what the server reported in a Server List Ping.
+type pingResult struct {
+	Version       string   `json:"version" yaml:"version"`
+	Protocol      int      `json:"protocol" yaml:"protocol"`
+	MOTD          string   `json:"motd" yaml:"motd"`
+	PlayersOnline int      `json:"players_online" yaml:"players_online"`
+	PlayersMax    int      `json:"players_max" yaml:"players_max"`
+	Players       []string `json:"players" yaml:"players"`
+	LatencyMillis int64    `json:"latency_ms" yaml:"latency_ms"`
+}
+
+// queryResult is what the server reported over the query protocol.
+type queryResult struct {
+	Map           string   `json:"map" yaml:"map"`
+	Software      string   `json:"software" yaml:"software"`
+	Version       string   `json:"version" yaml:"version"`
+	PlayersOnline int      `json:"players_online" yaml:"players_online"`
+	PlayersMax    int      `json:"players_max" yaml:"players_max"`
+	Players       []string `json:"players" yaml:"players"`
+	Plugins       []string `json:"plugins" yaml:"plugins"`
+}
+
+// backupResult is a single backup in a backup listing.
+type backupResult struct {
+	Name      string    `json:"name" yaml:"name"`
+	Timestamp string    `json:"timestamp" yaml:"timestamp"`
+	Time      time.Time `json:"time" yaml:"time"`
+	Size      int64     `json:"size" yaml:"size"`
+}
+
+// targetResult is the list of backups on a backup target.
+type targetResult struct {
+	Target  string         `json:"target" yaml:"target"`
+	Backups []backupResult `json:"backups" yaml:"backups"`
+}
+
+// updateResult is the result of the update command.
+type updateResult struct {
+	Provider string `json:"provider" yaml:"provider"`
+	Version  string `json:"version,omitempty" yaml:"version,omitempty"`
+	Build    int    `json:"build,omitempty" yaml:"build,omitempty"`
+	URL      string `json:"url,omitempty" yaml:"url,omitempty"`
+	File     string `json:"file" yaml:"file"`
+	Updated  bool   `json:"updated" yaml:"updated"`
+}
+
+// secretProperties are left out of the status result.
+var secretProperties = []string{"rcon.password", "management-server-secret"}
+
+// newStatusResult builds the status result from what we know about the server.
+func newStatusResult(name, maxMemory string, props properties.Map, running bool, status *ping.Status, stat *query.FullStat) statusResult {
+	result := statusResult{
+		Name:       name,
+		State:      stateStopped,
+		MaxMemory:  maxMemory,
+		Properties: make(map[string]string, len(props)),
+	}
+
+	if status != nil {
+		result.State = stateRunning
+	} else if running {
+		result.State = stateStarting
+	}
+
+	for key := range props {
+		result.Properties[key] = props.Get(key, "")
+	}
+	for _, key := range secretProperties {
+		delete(result.Properties, key)
+	}
+
+	if status != nil {
+		players := make([]string, 0, len(status.Players.Sample))
+		for _, player := range status.Players.Sample {
+			players = append(players, player.Name)
+		}
+
+		result.Ping = &pingResult{
+			Version:       status.Version.Name,
+			Protocol:      status.Version.Protocol,
+			MOTD:          status.Description.String(),
+			PlayersOnline: status.Players.Online,
+			PlayersMax:    status.Players.Max,
+			Players:       players,
+			LatencyMillis: status.Latency.Milliseconds(),
+		}
+	}
+
+	if stat != nil {
+		software, plugins := stat.Software()
+		result.Query = &queryResult{
+			Map:           stat.Map,
+			Software:      software,
+			Version:       stat.Version,
+			PlayersOnline: stat.NumPlayers,
+			PlayersMax:    stat.MaxPlayers,
+			Players:       stat.Players,
+			Plugins:       plugins,
+		}
+	}
+
+	return result
+}
+
+// newBackupResults converts a list of backups for a backup listing.
+func newBackupResults(backups []mcsmanager.Backup) []backupResult {
+	results := make([]backupResult, 0, len(backups))
+	for _, backup := range backups {
+		results = append(results, backupResult{
+			Name:      backup.Name,
+			Timestamp: mcsmanager.TrimArchiveExt(backup.Name),
+			Time:      backup.Time,
+			Size:      backup.Size,
+		})
+	}
+
+	return results
+}
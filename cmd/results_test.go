@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/EbonJaeger/mcsmanager"
+	"github.com/EbonJaeger/mcsmanager/ping"
+	"github.com/EbonJaeger/mcsmanager/properties"
+)
+
+func TestNewStatusResult(t *testing.T) {
+	props := properties.Map{
+		"motd":                     "A Minecraft Server",
+		"enable-rcon":              "true",
+		"rcon.password":            "hunter2",
+		"management-server-secret": "secret",
+	}
+
+	result := newStatusResult("Server 1", "4G", props, true, nil, nil)
+	if result.State != stateStarting {
+		t.Fatalf("expected state '%s', got '%s'", stateStarting, result.State)
+	}
+	for _, key := range secretProperties {
+		if _, ok := result.Properties[key]; ok {
+			t.Fatalf("expected '%s' to be left out", key)
+		}
+	}
+	if result.Properties["motd"] != "A Minecraft Server" || result.Properties["enable-rcon"] != "true" {
+		t.Fatalf("expected the other properties to be kept, got %v", result.Properties)
+	}
+
+	// The properties of the server are left alone
+	if props["rcon.password"] != "hunter2" {
+		t.Fatalf("expected the server properties to be unchanged")
+	}
+
+	status := &ping.Status{Latency: 15 * time.Millisecond}
+	status.Players.Online = 2
+	if result = newStatusResult("Server 1", "4G", props, true, status, nil); result.State != stateRunning {
+		t.Fatalf("expected state '%s', got '%s'", stateRunning, result.State)
+	}
+	if result.Ping == nil || result.Ping.PlayersOnline != 2 || result.Ping.LatencyMillis != 15 {
+		t.Fatalf("unexpected ping result: %+v", result.Ping)
+	}
+
+	if result = newStatusResult("Server 1", "4G", props, false, nil, nil); result.State != stateStopped {
+		t.Fatalf("expected state '%s', got '%s'", stateStopped, result.State)
+	}
+}
+
+func TestNewBackupResults(t *testing.T) {
+	at := time.Date(2021, 9, 2, 10, 0, 0, 0, time.UTC)
+	backups := []mcsmanager.Backup{
+		{Name: "2021-09-02T10:00:00+0000.tar.zst", Time: at, Size: 1024},
+	}
+
+	results := newBackupResults(backups)
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	expected := backupResult{Name: backups[0].Name, Timestamp: "2021-09-02T10:00:00+0000", Time: at, Size: 1024}
+	if results[0] != expected {
+		t.Fatalf("expected %+v, got %+v", expected, results[0])
+	}
+}
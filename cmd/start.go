@@ -10,6 +10,8 @@ import (
 	"github.com/DataDrake/cli-ng/v2/cmd"
 	"github.com/EbonJaeger/mcsmanager"
 	"github.com/EbonJaeger/mcsmanager/config"
+	"github.com/EbonJaeger/mcsmanager/proxy"
+	"github.com/EbonJaeger/mcsmanager/supervisor"
 	"github.com/EbonJaeger/mcsmanager/tmux"
 )
 
@@ -43,46 +45,69 @@ func StartServer(root *cmd.Root, c *cmd.Sub) {
 		return
 	}
 
-	// Check if the Minecraft EULA has been accepted
-	if !isEulaAccepted(prefix) {
+	// Check if the Minecraft EULA has been accepted. Proxies don't have one.
+	if !isProxy(prefix) && !isEulaAccepted(prefix) {
 		Log.Warnln("The Minecraft EULA has not been accepted!")
 		Log.Warnln("The server will not start until the EULA has been accepted.")
 		Log.Warnln("Open 'eula.txt' in a text editor, and change the line 'eula=false' to 'eula=true'.")
 		return
 	}
 
+	// Prune old logs before the server starts a new one
+	if err = pruneLogs(conf, prefix); err != nil {
+		Log.Fatalf("Unable to remove old logs: %s\n", err)
+	}
+
+	// Create tmux window
+	if err = launchServer(conf, prefix); err != nil {
+		Log.Fatalln("Error creating tmux session!", err)
+	} else {
+		Log.Goodln("Server started!")
+	}
+}
+
+// pruneLogs removes server logs that are too old, and then the oldest
+// logs until there are at most the max number of logs.
+func pruneLogs(conf config.Root, prefix string) error {
 	// Check of the logs dir exists before pruning logs
 	logsDir := filepath.Join(prefix, "logs")
-	if _, err := os.Stat(logsDir); err == nil {
-		// Prune old Logs
-		if pruned, err := mcsmanager.PruneOld(logsDir, conf.MainSettings.MaxAge, "latest.log"); err == nil {
-			if pruned > 0 {
-				Log.Infof("Removed %d log(s) due to age.\n", pruned)
-			}
-		} else {
-			Log.Fatalf("Unable to remove old logs: %s\n", err)
-		}
+	if _, err := os.Stat(logsDir); err != nil {
+		return nil
+	}
 
-		// Prune too many Logs
-		if pruned, err := mcsmanager.Prune(logsDir, conf.MainSettings.MaxLogs, "latest.log"); err == nil {
-			if pruned > 0 {
-				Log.Infof("Removed %d log(s) because over log limit.\n", pruned)
-			}
-		} else {
-			Log.Fatalf("Unable to remove old logs: %s\n", err.Error())
-		}
+	// Prune old Logs
+	pruned, err := mcsmanager.PruneOld(logsDir, conf.MainSettings.MaxAge, "latest.log")
+	if err != nil {
+		return err
+	}
+	if pruned > 0 {
+		Log.Infof("Removed %d log(s) due to age.\n", pruned)
+	}
+
+	// Prune too many Logs
+	if pruned, err = mcsmanager.Prune(logsDir, conf.MainSettings.MaxLogs, "latest.log"); err != nil {
+		return err
+	}
+	if pruned > 0 {
+		Log.Infof("Removed %d log(s) because over log limit.\n", pruned)
+	}
+
+	return nil
+}
+
+// launchServer starts the server in a new tmux window. Any record of the
+// server being stopped on purpose is cleared, so a supervisor watches it again.
+func launchServer(conf config.Root, prefix string) error {
+	if err := supervisor.ClearStopped(prefix); err != nil {
+		return err
 	}
 
 	// Build the Java command to start the server
 	javaCmd := buildJavaCmd(conf, prefix)
 
-	// Create tmux window
 	// TODO: out doesn't work as expected
-	if _, err = tmux.CreateSession(javaCmd, name); err != nil {
-		Log.Fatalln("Error creating tmux session!", err)
-	} else {
-		Log.Goodln("Server started!")
-	}
+	_, err := tmux.CreateSession(javaCmd, conf.MainSettings.ServerName, prefix)
+	return err
 }
 
 func buildJavaCmd(conf config.Root, prefix string) string {
@@ -94,9 +119,13 @@ func buildJavaCmd(conf config.Root, prefix string) string {
 		javaCmd = javaCmd + " " + strings.Join(*conf.JavaSettings.Flags, " ")
 	}
 
-	// Set the jar file
-	jarPath := filepath.Join(prefix, conf.MainSettings.ServerFile)
-	javaCmd = javaCmd + fmt.Sprintf(" -jar %s", jarPath)
+	// Set the jar file, or the argument file that launches the server
+	if argsFile := conf.ServerSettings.ArgsFile; argsFile != "" {
+		javaCmd = javaCmd + fmt.Sprintf(" @%s", argsFile)
+	} else {
+		jarPath := filepath.Join(prefix, conf.MainSettings.ServerFile)
+		javaCmd = javaCmd + fmt.Sprintf(" -jar %s", jarPath)
+	}
 
 	// Add any jar flags
 	if len(*conf.ServerSettings.Flags) > 0 {
@@ -140,3 +169,9 @@ func isEulaAccepted(prefix string) bool {
 
 	return false
 }
+
+// isProxy checks if a server is a Velocity or BungeeCord proxy.
+func isProxy(prefix string) bool {
+	_, err := proxy.Load(prefix)
+	return err == nil
+}
@@ -1,15 +1,20 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"io"
+	"net"
 	"os"
-	"path/filepath"
+	"strconv"
+	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/DataDrake/cli-ng/v2/cmd"
 	"github.com/EbonJaeger/mcsmanager/config"
+	"github.com/EbonJaeger/mcsmanager/ping"
 	"github.com/EbonJaeger/mcsmanager/properties"
+	"github.com/EbonJaeger/mcsmanager/query"
 	"github.com/EbonJaeger/mcsmanager/tmux"
 	"github.com/dustin/go-humanize"
 )
@@ -28,16 +33,21 @@ var Status = cmd.Sub{
 type StatusFlags struct {
 	ShowAll      bool `short:"a" desc:"Print all extra server info"`
 	ShowGameplay bool `short:"g" desc:"Print gameplay properties"`
+	ShowPlayers  bool `short:"l" long:"players" desc:"Print every online player and the plugins, using the query protocol"`
 	ShowRcon     bool `short:"r" desc:"Print Rcon configuration information"`
 	ShowWorld    bool `short:"w" desc:"Print main world informations"`
 }
 
+// pingTimeout is how long to wait for the server to answer a ping.
+const pingTimeout = 5 * time.Second
+
 // Color codes for terminal colors, matching colors from Waterlog.
 const (
-	red   = "\033[49;38;5;160m"
-	green = "\033[49;38;5;040m"
-	blue  = "\033[49;38;5;045m"
-	reset = "\033[0m"
+	red    = "\033[49;38;5;160m"
+	green  = "\033[49;38;5;040m"
+	blue   = "\033[49;38;5;045m"
+	yellow = "\033[49;38;5;220m"
+	reset  = "\033[0m"
 )
 
 // ServerStatus handles the `Status` command and prints out various
@@ -53,36 +63,66 @@ func ServerStatus(root *cmd.Root, c *cmd.Sub) {
 		Log.Fatalf("Error loading server config: %s\n", err)
 	}
 
-	// Open and read the server.properties file
-	path := filepath.Join(prefix, "server.properties")
-	f, err := os.Open(path)
+	// Read the server properties from the file
+	props, err := properties.Load(prefix)
 	if err != nil {
-		Log.Fatalf("Error opening server.properties file: %s\n", err)
+		Log.Fatalf("Error reading server.properties file: %s\n", err)
+	}
+
+	// Ask the server how it's doing if its window is open
+	name := conf.MainSettings.ServerName
+	running := tmux.IsServerRunning(name)
+	var status *ping.Status
+	if running {
+		address := net.JoinHostPort(props.Get("server-ip", "127.0.0.1"), props.Get("server-port", strconv.Itoa(ping.DefaultPort)))
+		if status, err = ping.Ping(address, pingTimeout); err != nil {
+			Log.Warnf("Unable to ping the server: %s\n", err)
+		}
+	}
+
+	// Get the full player list if it was asked for
+	flags := c.Flags.(*StatusFlags)
+	var stat *query.FullStat
+	if running && (flags.ShowAll || flags.ShowPlayers || root.Flags.(*GlobalFlags).IsStructured()) {
+		if stat, err = queryServer(prefix); err != nil {
+			Log.Warnf("Unable to query the server: %s\n", err)
+		}
+	}
+
+	if writeResult(root, newStatusResult(name, conf.JavaSettings.MaxMemory, props, running, status, stat)) {
+		return
 	}
-	defer f.Close()
 
-	raw, err := io.ReadAll(f)
+	print(name, conf.JavaSettings.MaxMemory, flags, props, running, status, stat)
+}
+
+// queryServer gets the full stat from the server with the query protocol.
+func queryServer(prefix string) (*query.FullStat, error) {
+	settings, err := query.LoadSettings(prefix)
 	if err != nil {
-		Log.Fatalf("Error reading server.properties file: %s\n", err)
+		return nil, err
+	}
+	if !settings.Enabled {
+		return nil, errors.New("set 'enable-query=true' in server.properties and restart the server to see all players")
 	}
 
-	// Read the server properties from the file
-	props, err := properties.Read(raw)
+	client, err := query.Dial(settings.Address, pingTimeout)
 	if err != nil {
-		Log.Fatalf("Error reading props: %s\n", err)
+		return nil, err
 	}
+	defer client.Close()
 
-	print(conf.MainSettings.ServerName, conf.JavaSettings.MaxMemory, c.Flags.(*StatusFlags), props)
+	return client.Full()
 }
 
 // print will write various server settings in a nice and readable
 // format to stdout.
-func print(name string, maxMemory string, flags *StatusFlags, props properties.Map) {
-	var running string
-	if tmux.IsServerRunning(name) {
-		running = fmt.Sprintf("%sYES", green)
-	} else {
-		running = fmt.Sprintf("%sNO", red)
+func print(name string, maxMemory string, flags *StatusFlags, props properties.Map, running bool, status *ping.Status, stat *query.FullStat) {
+	state := fmt.Sprintf("%sNO", red)
+	if status != nil {
+		state = fmt.Sprintf("%sYES", green)
+	} else if running {
+		state = fmt.Sprintf("%sSTARTING %s(window open, but not accepting connections)", yellow, reset)
 	}
 
 	// Make the memory printout a bit nicer
@@ -97,7 +137,23 @@ func print(name string, maxMemory string, flags *StatusFlags, props properties.M
 	fmt.Fprintf(tw, "%s========== Status of '%s' ==========\n", blue, name)
 	fmt.Fprintf(tw, "%sServer Address:\t%s%s\t%sServer Port:\t%s%s\n", blue, reset, props["server-ip"], blue, reset, props["server-port"])
 	fmt.Fprintf(tw, "%sAllocated Memory:\t%s%s\t%sMax Players:\t%s%s\n", blue, reset, bytesDisplay, blue, reset, props["max-players"])
-	fmt.Fprintf(tw, "%sRunning: %s\n", blue, running)
+	fmt.Fprintf(tw, "%sRunning: %s\n", blue, state)
+
+	// Print what the server reports about itself
+	if status != nil {
+		fmt.Fprintln(tw, "")
+
+		fmt.Fprintf(tw, "%sVersion:\t%s%s\t%sLatency:\t%s%v\n", blue, reset, status.Version.Name, blue, reset, status.Latency.Round(time.Millisecond))
+		fmt.Fprintf(tw, "%sMOTD:\t%s%s\n", blue, reset, strings.ReplaceAll(status.Description.String(), "\n", " "))
+		fmt.Fprintf(tw, "%sPlayers Online:\t%s%d/%d\n", blue, reset, status.Players.Online, status.Players.Max)
+		if len(status.Players.Sample) > 0 {
+			names := make([]string, 0, len(status.Players.Sample))
+			for _, player := range status.Players.Sample {
+				names = append(names, player.Name)
+			}
+			fmt.Fprintf(tw, "%sPlayers:\t%s%s\n", blue, reset, strings.Join(names, ", "))
+		}
+	}
 
 	// Print general gameplay settings
 	if flags.ShowAll || flags.ShowGameplay {
@@ -119,7 +175,7 @@ func print(name string, maxMemory string, flags *StatusFlags, props properties.M
 	if flags.ShowAll || flags.ShowRcon {
 		fmt.Fprintln(tw, "")
 
-		rconEnabled, _ := props["enable-rcon"].(bool)
+		rconEnabled := props.Get("enable-rcon", "false") == "true"
 		fmt.Fprintf(tw, "%sRcon:\n", blue)
 		fmt.Fprintf(tw, "\t%sEnabled: \t%s%t\n", blue, reset, rconEnabled)
 		if rconEnabled {
@@ -132,6 +188,21 @@ func print(name string, maxMemory string, flags *StatusFlags, props properties.M
 		}
 	}
 
+	// Print everything the server reports over the query protocol
+	if stat != nil {
+		fmt.Fprintln(tw, "")
+
+		software, plugins := stat.Software()
+		if software == "" {
+			software = "Vanilla " + stat.Version
+		}
+		fmt.Fprintf(tw, "%sQuery:\n", blue)
+		fmt.Fprintf(tw, "\t%sMap: \t%s%s\n", blue, reset, stat.Map)
+		fmt.Fprintf(tw, "\t%sSoftware: \t%s%s\n", blue, reset, software)
+		fmt.Fprintf(tw, "\t%sPlayers (%d/%d): \t%s%s\n", blue, stat.NumPlayers, stat.MaxPlayers, reset, strings.Join(stat.Players, ", "))
+		fmt.Fprintf(tw, "\t%sPlugins (%d): \t%s%s\n", blue, len(plugins), reset, strings.Join(plugins, ", "))
+	}
+
 	// Print main world settings
 	if flags.ShowAll || flags.ShowWorld {
 		fmt.Fprintln(tw, "")
@@ -5,6 +5,8 @@ import (
 
 	"github.com/DataDrake/cli-ng/v2/cmd"
 	"github.com/EbonJaeger/mcsmanager/config"
+	"github.com/EbonJaeger/mcsmanager/proxy"
+	"github.com/EbonJaeger/mcsmanager/supervisor"
 	"github.com/EbonJaeger/mcsmanager/tmux"
 )
 
@@ -38,8 +40,29 @@ func StopServer(root *cmd.Root, c *cmd.Sub) {
 
 	Log.Infoln("Attempting to stop the server...")
 
-	// Stop the server gracefully
-	err = tmux.Exec("stop", name)
+	if stopServer(prefix, name) {
+		Log.Println("")
+		Log.Goodln("Server stopped successfully!")
+	}
+}
+
+// stopServer tells the server to stop, and waits up to 20 seconds for it
+// to close. If it doesn't close in time, its window is killed instead.
+// Returns true if the server stopped normally.
+//
+// The server is marked as stopped on purpose first, so a supervisor
+// doesn't think that it crashed.
+func stopServer(prefix, name string) bool {
+	if err := supervisor.MarkStopped(prefix); err != nil {
+		Log.Warnf("Unable to mark the server as stopped: %s\n", err)
+	}
+
+	// Stop the server gracefully. Proxies have their own command for it.
+	command := "stop"
+	if conf, err := proxy.Load(prefix); err == nil {
+		command = conf.StopCommand()
+	}
+	_, err := sendCommand(prefix, name, command)
 
 	// Wait 20 seconds for server to stop
 	done := make(chan bool)
@@ -50,11 +73,10 @@ func StopServer(root *cmd.Root, c *cmd.Sub) {
 		Log.Errorln("Could not stop the server normally! Attempting to force close...")
 		tmux.KillWindow(name)
 		Log.Warnln("Server window force-killed!")
-		return
+		return false
 	}
 
-	Log.Println("")
-	Log.Goodln("Server stopped successfully!")
+	return true
 }
 
 func pollSessions(done chan bool, name string) {
@@ -65,7 +87,9 @@ func pollSessions(done chan bool, name string) {
 		case <-ticker.C: // Tick received
 			tickCount++
 			if !tmux.IsServerRunning(name) { // Session no longer running
+				ticker.Stop()
 				done <- true
+				return
 			} else { // Session still running
 				if tickCount == 20 { // Stop polling after 20 seconds
 					done <- false
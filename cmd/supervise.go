@@ -0,0 +1,291 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/DataDrake/cli-ng/v2/cmd"
+	"github.com/EbonJaeger/mcsmanager"
+	"github.com/EbonJaeger/mcsmanager/config"
+	"github.com/EbonJaeger/mcsmanager/supervisor"
+	"github.com/EbonJaeger/mcsmanager/tmux"
+	"github.com/dustin/go-humanize"
+)
+
+// superviseInterval is how often the supervisor checks on the server.
+const superviseInterval = 5 * time.Second
+
+// SuperviseArgs contains the command arguments for the supervise command.
+type SuperviseArgs struct {
+	Args []string `zero:"true" desc:"Use \"history\" to list the crashes that the supervisor has seen"`
+}
+
+// Supervise watches the Minecraft server and restarts it if it crashes.
+var Supervise = cmd.Sub{
+	Name:  "supervise",
+	Alias: "v",
+	Short: "Watch the Minecraft server and restart it if it crashes",
+	Args:  &SuperviseArgs{},
+	Run:   RunSupervisor,
+}
+
+// RunSupervisor watches the server until it is interrupted, restarting the
+// server whenever it crashes. Servers that are stopped on purpose with
+// mcsmanager or the `stop` console command are left alone.
+func RunSupervisor(root *cmd.Root, c *cmd.Sub) {
+	args := c.Args.(*SuperviseArgs).Args
+	if len(args) > 0 && (args[0] != "history" || len(args) > 1) {
+		Log.Errorln("Unknown arguments!")
+		Log.Errorln("")
+		Log.Errorln("USAGE:")
+		Log.Errorf("\tmcsmanager %s\n", c.Name)
+		Log.Errorln("OR")
+		Log.Errorf("\tmcsmanager %s history\n", c.Name)
+		os.Exit(1)
+	}
+
+	prefix, err := root.Flags.(*GlobalFlags).GetPathPrefix()
+	if err != nil {
+		Log.Fatalf("Error getting the working directory: %s\n", err)
+	}
+
+	conf, err := config.Load(prefix)
+	if err != nil {
+		Log.Fatalf("Error loading server config: %s\n", err)
+	}
+
+	if len(args) > 0 {
+		history, err := supervisor.LoadHistory(prefix)
+		if err != nil {
+			Log.Fatalf("Error reading crash history: %s\n", err)
+		}
+		if !writeResult(root, history) {
+			printCrashes(history)
+		}
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err = superviseServer(ctx, conf, prefix); err != nil {
+		Log.Fatalf("Invalid supervisor settings: %s\n", err)
+	}
+}
+
+// superviseServer watches the server until the context is cancelled.
+//
+// A server is considered crashed if its window closes and it wasn't marked
+// as stopped by mcsmanager, and it either didn't log a normal shutdown or
+// wrote a crash report. If the watchdog says that the server stopped
+// responding and it stays that way, it is killed and treated as crashed.
+func superviseServer(ctx context.Context, conf config.Root, prefix string) error {
+	settings := conf.Supervisor
+	minBackoff, err := parseSetting(settings.MinBackoff, 10*time.Second)
+	if err != nil {
+		return err
+	}
+	maxBackoff, err := parseSetting(settings.MaxBackoff, 5*time.Minute)
+	if err != nil {
+		return err
+	}
+	hangTimeout, err := parseSetting(settings.HangTimeout, time.Minute)
+	if err != nil {
+		return err
+	}
+	backoff := &supervisor.Backoff{
+		Min:         minBackoff,
+		Max:         maxBackoff,
+		Window:      time.Hour,
+		MaxRestarts: settings.MaxRestarts,
+	}
+
+	name := conf.MainSettings.ServerName
+	logPath := filepath.Join(prefix, "logs", "latest.log")
+
+	// Only look at what the server logs from now on
+	offset, err := mcsmanager.LogSize(logPath)
+	if err != nil {
+		return err
+	}
+	reader := mcsmanager.NewLogReader(logPath, offset)
+
+	running := tmux.IsServerRunning(name)
+	started := time.Now()
+	stopping := false
+	var hungAt time.Time
+
+	Log.Goodf("Supervising '%s'\n", name)
+
+	ticker := time.NewTicker(superviseInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			Log.Infoln("Supervisor stopped")
+			return nil
+		case <-ticker.C:
+		}
+
+		now := tmux.IsServerRunning(name)
+
+		// Someone else started the server
+		if now && !running {
+			running, started, stopping = true, time.Now(), false
+			hungAt = time.Time{}
+			reader = mcsmanager.NewLogReader(logPath, 0)
+			backoff.Reset()
+			Log.Infoln("Server started, watching it for crashes")
+		}
+
+		// Look for a normal shutdown or the watchdog in the log
+		lines, err := reader.Lines()
+		if err != nil {
+			Log.Warnf("Unable to read the server log: %s\n", err)
+		}
+		for _, line := range lines {
+			if strings.Contains(line, supervisor.StopLine) {
+				stopping = true
+			}
+			if strings.Contains(line, supervisor.HangLine) && hungAt.IsZero() {
+				Log.Warnln("The server watchdog says the server stopped responding")
+				hungAt = time.Now()
+			}
+		}
+
+		reason := ""
+		switch {
+		case now && !hungAt.IsZero() && time.Since(hungAt) > hangTimeout:
+			Log.Errorf("Server hasn't responded for %v, killing it\n", hangTimeout)
+			tmux.KillWindow(name)
+			reason = "not responding"
+		case !now && running:
+			reason = "exited"
+		default:
+			continue
+		}
+		running = false
+
+		reports, err := supervisor.NewReports(prefix, started)
+		if err != nil {
+			Log.Warnf("Unable to look for crash reports: %s\n", err)
+		}
+
+		if reason == "exited" && (supervisor.WasStopped(prefix) || (stopping && len(reports) == 0)) {
+			Log.Infoln("Server was stopped, not restarting it")
+			continue
+		}
+
+		restarted := handleCrash(ctx, conf, prefix, backoff)
+		recordCrash(prefix, reason, reports, restarted)
+
+		if restarted {
+			running, started, stopping = true, time.Now(), false
+			hungAt = time.Time{}
+			reader = mcsmanager.NewLogReader(logPath, 0)
+		}
+	}
+}
+
+// handleCrash waits for the backoff delay, and then starts the server again.
+// Returns false if the server wasn't restarted, e.g. because it crashed too
+// many times, or someone else started or stopped it in the meantime.
+func handleCrash(ctx context.Context, conf config.Root, prefix string, backoff *supervisor.Backoff) bool {
+	name := conf.MainSettings.ServerName
+
+	delay, ok := backoff.Next(time.Now())
+	if !ok {
+		Log.Errorf("Server crashed %d times in the last hour, not restarting it until it is started manually\n", conf.Supervisor.MaxRestarts)
+		return false
+	}
+
+	Log.Errorf("Server crashed! Restarting it in %v...\n", delay)
+	if err := sleep(ctx, delay); err != nil {
+		return false
+	}
+
+	if tmux.IsServerRunning(name) || supervisor.WasStopped(prefix) {
+		Log.Infoln("Server was started or stopped by someone else, not restarting it")
+		return false
+	}
+
+	if err := pruneLogs(conf, prefix); err != nil {
+		Log.Warnf("Unable to remove old logs: %s\n", err)
+	}
+
+	if err := launchServer(conf, prefix); err != nil {
+		Log.Errorf("Error restarting the server: %s\n", err)
+		return false
+	}
+
+	backoff.Restarted(time.Now())
+	Log.Goodln("Server restarted!")
+	return true
+}
+
+// recordCrash adds a crash to the crash history, along with any crash
+// reports that the server wrote.
+func recordCrash(prefix, reason string, reports []string, restarted bool) {
+	crash := supervisor.Crash{
+		Time:      time.Now(),
+		Reason:    reason,
+		Reports:   reports,
+		Restarted: restarted,
+	}
+
+	if len(reports) > 0 {
+		latest := reports[len(reports)-1]
+		description, err := supervisor.ReportDescription(prefix, latest)
+		if err != nil {
+			Log.Warnf("Unable to read crash report: %s\n", err)
+		}
+		crash.Description = description
+		Log.Errorf("Crash report: %s\n", filepath.Join(supervisor.CrashReportsDir, latest))
+	}
+
+	if err := supervisor.AddToHistory(prefix, crash); err != nil {
+		Log.Warnf("Unable to save crash history: %s\n", err)
+	}
+}
+
+// printCrashes prints the crash history in a table.
+func printCrashes(history []supervisor.Crash) {
+	if len(history) == 0 {
+		Log.Infoln("The server hasn't crashed yet")
+		return
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%sTime\tReason\tRestarted\tDescription\n", blue)
+	for _, crash := range history {
+		restarted := "no"
+		if crash.Restarted {
+			restarted = "yes"
+		}
+		fmt.Fprintf(tw, "%s%s\t%s\t%s\t%s\n", reset, humanize.Time(crash.Time), crash.Reason, restarted, crash.Description)
+	}
+	tw.Flush()
+}
+
+// parseSetting parses a duration from the config, using the fallback
+// if it isn't set.
+func parseSetting(raw string, fallback time.Duration) (time.Duration, error) {
+	if raw == "" {
+		return fallback, nil
+	}
+
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("bad duration '%s'", raw)
+	}
+
+	return d, nil
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/DataDrake/cli-ng/v2/cmd"
 	"github.com/DataDrake/waterlog"
+	"github.com/EbonJaeger/mcsmanager/config"
 )
 
 // DownloaderArgs contains the command arguments for commands that download
@@ -30,18 +31,38 @@ func PrintDownloaderUsage(sub *cmd.Sub) {
 	Log.Errorf("\tmcsmanager %s <provider> <version>\n", sub.Name)
 	Log.Errorln("")
 	Log.Errorln("PROVIDERS:")
-	Log.Errorln("\tpaper")
+	Log.Errorln("\tpaper, velocity, waterfall, folia")
+	Log.Errorln("\tvanilla (a version, \"release\", or \"snapshot\")")
+	Log.Errorln("\tfabric, quilt")
+	Log.Errorln("\tforge, neoforge")
+	Log.Errorln("\tpurpur, pufferfish")
 }
 
 // GlobalFlags holds the flags for the root command.
 type GlobalFlags struct {
-	Path string `short:"p" long:"path" arg:"true" desc:"Set the path of the Minecraft server"`
+	Path   string `short:"p" long:"path" arg:"true" desc:"Set the path of the Minecraft server"`
+	Server string `short:"s" long:"server" arg:"true" desc:"Use a server from the inventory by its name"`
+	All    bool   `long:"all" desc:"Run the command for every server in the inventory"`
+	Output string `short:"O" long:"output" arg:"true" desc:"Print results as text, json, or yaml"`
 }
 
-// GetPathPrefix gets the server path from the command line flags. If there isn't one,
+// GetPathPrefix gets the server path from the command line flags, either
+// directly or by looking up the server in the inventory. If there isn't one,
 // return the current working directory.
 func (f GlobalFlags) GetPathPrefix() (string, error) {
 	prefix := f.Path
+	if prefix == "" && f.Server != "" {
+		inv, err := config.LoadInventory()
+		if err != nil {
+			return "", err
+		}
+
+		server, err := inv.Find(f.Server)
+		if err != nil {
+			return "", err
+		}
+		prefix = server.Path
+	}
 	if prefix == "" {
 		var err error
 		prefix, err = os.Getwd()
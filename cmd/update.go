@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/DataDrake/cli-ng/v2/cmd"
 	"github.com/EbonJaeger/mcsmanager/config"
@@ -15,16 +17,24 @@ var Update = cmd.Sub{
 	Alias: "u",
 	Short: "Update the jar file for the Minecraft server",
 	Args:  &DownloaderArgs{},
+	Flags: &UpdateFlags{},
 	Run:   UpdateServer,
 }
 
+// UpdateFlags holds the flags for the update command.
+type UpdateFlags struct {
+	Build    int  `short:"b" long:"build" desc:"Install this build instead of the latest one, for PaperMC projects"`
+	Rollback bool `short:"r" long:"rollback" desc:"Go back to the server jar that was installed before the last update of a PaperMC project"`
+}
+
 // UpdateServer downloads the specified server file.
 func UpdateServer(root *cmd.Root, c *cmd.Sub) {
-	if !c.Args.(*DownloaderArgs).IsValid() {
+	flags := c.Flags.(*UpdateFlags)
+	args := c.Args.(*DownloaderArgs).Args
+	if !flags.Rollback && !c.Args.(*DownloaderArgs).IsValid() {
 		PrintDownloaderUsage(c)
 		return
 	}
-	args := c.Args.(*DownloaderArgs).Args
 
 	prefix, err := root.Flags.(*GlobalFlags).GetPathPrefix()
 	if err != nil {
@@ -47,20 +57,114 @@ func UpdateServer(root *cmd.Root, c *cmd.Sub) {
 	fileName := conf.MainSettings.ServerFile
 	outFile := filepath.Join(prefix, fileName)
 
+	if flags.Rollback {
+		rollbackServer(root, conf, prefix, args)
+		return
+	}
+
 	// Figure out our upgrade provider
 	prov := provider.MatchProvider(args)
 	if prov == nil {
 		Log.Fatalf("Unable to get a download provider")
 	}
 
+	result := updateResult{File: fileName, Updated: true}
+	if len(args) == 1 {
+		result.Provider, result.URL = strings.ToLower(provider.FileProvider), args[0]
+	} else {
+		result.Provider, result.Version = strings.ToLower(args[0]), args[1]
+	}
+
+	// Pin the build, if one was given
+	if flags.Build != 0 {
+		paper, ok := prov.(provider.Paper)
+		if !ok {
+			Log.Fatalln("A build can only be given for PaperMC projects")
+		}
+		paper.Build = flags.Build
+		prov, result.Build = paper, flags.Build
+	}
+
 	Log.Infoln("Downloading new server jar...")
 	if err := prov.Download(outFile); err != nil {
 		if err == provider.ErrAlreadyUpToDate {
 			Log.Goodln("Server jar is already up to date")
+			result.Updated = false
 		} else {
 			Log.Fatalln("Error downloading file:", err)
 		}
 	} else {
 		Log.Goodln("Server jar updated!")
 	}
+
+	if err := applyLauncher(conf, prefix, prov); err != nil {
+		Log.Fatalf("Error updating the server config: %s\n", err)
+	}
+
+	writeResult(root, result)
+}
+
+// rollbackServer goes back to the server jar that was installed before the
+// last update of a PaperMC project. The project can be given, or else it is
+// found from the saved builds in the server directory.
+func rollbackServer(root *cmd.Root, conf config.Root, prefix string, args []string) {
+	if len(args) > 1 {
+		Log.Fatalln("Usage: mcsmanager update --rollback [provider]")
+	}
+
+	project := ""
+	if len(args) == 1 {
+		project = strings.ToLower(args[0])
+	} else {
+		for _, name := range provider.PaperProjects {
+			if _, err := os.Stat(filepath.Join(prefix, provider.PreviousBuildFile(name))); err != nil {
+				continue
+			}
+			if project != "" {
+				Log.Fatalf("Found earlier builds of both %s and %s, so the provider to roll back is needed\n", project, name)
+			}
+			project = name
+		}
+	}
+	if project == "" {
+		Log.Fatalln("There is no earlier build to roll back to")
+	}
+
+	Log.Infoln("Rolling back to the previous server jar...")
+	build, err := provider.RollbackPaper(filepath.Join(prefix, conf.MainSettings.ServerFile), project)
+	if err != nil {
+		Log.Fatalf("Error rolling back: %s\n", err)
+	}
+	Log.Goodf("Rolled back to %s %s build %d\n", project, build.Version, build.Build)
+
+	if err = applyLauncher(conf, prefix, provider.Paper{}); err != nil {
+		Log.Fatalf("Error updating the server config: %s\n", err)
+	}
+
+	writeResult(root, updateResult{
+		Provider: project,
+		Version:  build.Version,
+		Build:    build.Build,
+		File:     conf.MainSettings.ServerFile,
+		Updated:  true,
+	})
+}
+
+// applyLauncher updates how the server is launched after a download.
+// Servers installed by a provider like Forge are launched with the argument
+// file that it created, and other servers launch the server jar directly.
+func applyLauncher(conf config.Root, prefix string, prov provider.Provider) error {
+	argsFile := ""
+	if launcher, ok := prov.(provider.Launcher); ok {
+		argsFile = launcher.ArgsFile()
+	}
+	if conf.ServerSettings.ArgsFile == argsFile {
+		return nil
+	}
+
+	if argsFile != "" {
+		Log.Infof("The server will be launched with '%s'\n", argsFile)
+	}
+	conf.ServerSettings.ArgsFile = argsFile
+	return conf.Save(prefix)
 }
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/DataDrake/cli-ng/v2/cmd"
+	"github.com/EbonJaeger/mcsmanager"
+	"github.com/EbonJaeger/mcsmanager/config"
+)
+
+// VerifyBackups checks the integrity of every backup, or only the given one.
+// Archives of backup sets and single worlds are checked as well, unless a set
+// or world is given in the flags, in which case only its archives are checked.
+// Any missing, corrupt, or extra files are reported, and the program exits
+// with a non-zero exit code if any problems were found.
+func VerifyBackups(root *cmd.Root, flags *BackupFlags, args []string) {
+	prefix, err := root.Flags.(*GlobalFlags).GetPathPrefix()
+	if err != nil {
+		Log.Fatalf("Error getting the working directory: %s\n", err)
+	}
+
+	conf, err := config.Load(prefix)
+	if err != nil {
+		Log.Fatalf("Error loading server config: %s\n", err)
+	}
+
+	backupDir := getBackupDir(conf, prefix)
+	dirs := verifyDirs(conf, backupDir, flags, len(args) == 0)
+
+	var repo *mcsmanager.Repository
+	backups := make([]mcsmanager.Backup, 0)
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); os.IsNotExist(err) && dir != backupDir {
+			continue
+		}
+
+		var found []mcsmanager.Backup
+		repo, found = loadBackups(conf, dir)
+
+		// Only check the backup we were given
+		if len(args) > 0 {
+			backup, err := mcsmanager.FindBackup(found, args[0])
+			if err != nil {
+				Log.Fatalf("Unable to find backup: %s\n", err)
+			}
+			found = []mcsmanager.Backup{backup}
+		}
+
+		// Show which set or world the archives belong to
+		if rel, err := filepath.Rel(backupDir, dir); err == nil && rel != "." {
+			for i := range found {
+				found[i].Name = filepath.ToSlash(filepath.Join(rel, found[i].Name))
+			}
+		}
+
+		backups = append(backups, found...)
+	}
+
+	if len(backups) == 0 {
+		Log.Infoln("There are no backups to verify")
+		return
+	}
+
+	failed := 0
+	for _, backup := range backups {
+		Log.Infof("Verifying '%s'...\n", backup.Name)
+
+		var result *mcsmanager.Verification
+		if repo != nil {
+			result, err = repo.Verify(backup.Name)
+		} else {
+			result, err = verifyArchive(backup)
+		}
+
+		if err != nil {
+			Log.Errorf("Backup '%s' is damaged: %s\n", backup.Name, err)
+			failed++
+			continue
+		}
+
+		if !result.OK() {
+			for _, name := range result.Missing {
+				Log.Errorf("\tMissing: %s\n", name)
+			}
+			for _, name := range result.Corrupt {
+				Log.Errorf("\tCorrupt: %s\n", name)
+			}
+			for _, name := range result.Extra {
+				Log.Errorf("\tExtra: %s\n", name)
+			}
+			Log.Errorf("Backup '%s' failed verification!\n", backup.Name)
+			failed++
+			continue
+		}
+
+		Log.Goodf("Backup '%s' is OK\n", backup.Name)
+	}
+
+	if failed > 0 {
+		Log.Errorf("%d of %d backup(s) failed verification!\n", failed, len(backups))
+		os.Exit(1)
+	}
+
+	Log.Goodf("All %d backup(s) verified!\n", len(backups))
+}
+
+// verifyDirs returns the directories with the backups to verify. That is the
+// directory of the set or world in the flags, or the backup directory along
+// with the directories of every backup set and single world if all is set.
+func verifyDirs(conf config.Root, backupDir string, flags *BackupFlags, all bool) []string {
+	repository := conf.BackupSettings.Backend == config.BackendRepository
+
+	switch {
+	case flags.World != "" && flags.Set != "":
+		Log.Fatalln("Only one of '--world' or '--set' can be used at a time")
+	case repository && (flags.World != "" || flags.Set != ""):
+		Log.Fatalln("World and backup set backups are only supported for archives")
+	case flags.World != "":
+		// World names are used as directory names
+		if filepath.Base(flags.World) != flags.World {
+			Log.Fatalf("Invalid world name: '%s'\n", flags.World)
+		}
+		return []string{filepath.Join(backupDir, worldsDir, flags.World)}
+	case flags.Set != "":
+		set, ok := findSet(conf, flags.Set)
+		if !ok {
+			Log.Fatalf("No backup set found with the name '%s'\n", flags.Set)
+		}
+		return []string{filepath.Join(backupDir, setsDir, set.Name)}
+	}
+
+	dirs := []string{backupDir}
+	if repository || !all {
+		return dirs
+	}
+
+	for _, sub := range []string{setsDir, worldsDir} {
+		entries, err := os.ReadDir(filepath.Join(backupDir, sub))
+		if err != nil {
+			if !os.IsNotExist(err) {
+				Log.Fatalf("Error reading backups: %s\n", err)
+			}
+			continue
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() {
+				dirs = append(dirs, filepath.Join(backupDir, sub, entry.Name()))
+			}
+		}
+	}
+
+	return dirs
+}
+
+// verifyArchive checks a backup archive against its manifest. Archives
+// without a manifest only have their framing checked.
+func verifyArchive(backup mcsmanager.Backup) (*mcsmanager.Verification, error) {
+	manifest, err := mcsmanager.LoadManifest(mcsmanager.ManifestPath(backup.Path))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		Log.Warnf("No manifest found for '%s', only checking the archive format\n", backup.Name)
+	}
+
+	return mcsmanager.VerifyArchive(backup.Path, manifest)
+}
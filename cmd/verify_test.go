@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"io"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/DataDrake/waterlog"
+	"github.com/EbonJaeger/mcsmanager/config"
+)
+
+func TestVerifyDirs(t *testing.T) {
+	Log = waterlog.New(io.Discard, "", 0)
+
+	backupDir := t.TempDir()
+	createArchives(t, backupDir, 1)
+	createArchives(t, filepath.Join(backupDir, setsDir, "plugins"), 1)
+	createArchives(t, filepath.Join(backupDir, worldsDir, "world"), 1)
+
+	conf := config.Default()
+	conf.BackupSettings.Sets = []config.BackupSet{{Name: "plugins"}}
+
+	cases := []struct {
+		flags    BackupFlags
+		all      bool
+		expected []string
+	}{
+		{BackupFlags{}, true, []string{backupDir, filepath.Join(backupDir, setsDir, "plugins"), filepath.Join(backupDir, worldsDir, "world")}},
+		{BackupFlags{}, false, []string{backupDir}},
+		{BackupFlags{Set: "plugins"}, true, []string{filepath.Join(backupDir, setsDir, "plugins")}},
+		{BackupFlags{World: "world"}, false, []string{filepath.Join(backupDir, worldsDir, "world")}},
+	}
+
+	for _, c := range cases {
+		dirs := verifyDirs(conf, backupDir, &c.flags, c.all)
+		if !reflect.DeepEqual(dirs, c.expected) {
+			t.Fatalf("expected %v for %+v, got %v", c.expected, c.flags, dirs)
+		}
+	}
+}
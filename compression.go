@@ -0,0 +1,138 @@
+package mcsmanager
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// Compression formats that backup archives can be written in.
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+	CompressionXz   = "xz"
+)
+
+// compressionLevels holds the compression formats in the order of
+// the backup command's `--level` flag.
+var compressionLevels = []string{CompressionNone, CompressionGzip, CompressionZstd, CompressionXz}
+
+// compressionExtensions maps each compression format to the file
+// extension of its archives.
+var compressionExtensions = map[string]string{
+	CompressionNone: ".tar",
+	CompressionGzip: ".tar.gz",
+	CompressionZstd: ".tar.zst",
+	CompressionXz:   ".tar.xz",
+}
+
+// CompressionOptions holds the settings for a compressor.
+type CompressionOptions struct {
+	// Level is the compression level. For gzip this is 1-9, and for
+	// zstd it is 1-22. Zero uses the best gzip compression and the
+	// default zstd level. It has no effect on xz.
+	Level int
+
+	// Threads is the number of threads zstd may use. Zero uses
+	// one thread per CPU.
+	Threads int
+}
+
+// CompressionFromLevel returns the compression format for a compression
+// level given on the command line.
+func CompressionFromLevel(level int) (string, error) {
+	if level < 0 || level >= len(compressionLevels) {
+		return "", fmt.Errorf("compression level must be between 0 and %d", len(compressionLevels)-1)
+	}
+
+	return compressionLevels[level], nil
+}
+
+// ArchiveExt returns the file extension for archives with the given
+// compression format.
+func ArchiveExt(compression string) (string, error) {
+	if compression == "" {
+		compression = CompressionNone
+	}
+
+	ext, ok := compressionExtensions[strings.ToLower(compression)]
+	if !ok {
+		return "", fmt.Errorf("compression format not supported: %s", compression)
+	}
+
+	return ext, nil
+}
+
+// NewCompressor wraps a writer with a compressor for the given format.
+// The compressor must be closed to flush everything to the writer.
+func NewCompressor(w io.Writer, compression string, opts CompressionOptions) (io.WriteCloser, error) {
+	if compression == "" {
+		compression = CompressionNone
+	}
+
+	switch strings.ToLower(compression) {
+	case CompressionNone:
+		return nopWriteCloser{w}, nil
+	case CompressionGzip:
+		level := opts.Level
+		if level == 0 {
+			level = gzip.BestCompression
+		}
+		return gzip.NewWriterLevel(w, level)
+	case CompressionZstd:
+		level := zstd.SpeedDefault
+		if opts.Level != 0 {
+			level = zstd.EncoderLevelFromZstd(opts.Level)
+		}
+		threads := opts.Threads
+		if threads == 0 {
+			threads = runtime.GOMAXPROCS(0)
+		}
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(level), zstd.WithEncoderConcurrency(threads))
+	case CompressionXz:
+		return xz.NewWriter(w)
+	default:
+		return nil, fmt.Errorf("compression format not supported: %s", compression)
+	}
+}
+
+// newDecompressor wraps a reader with a decompressor matching the
+// extension of an archive's file name.
+func newDecompressor(r io.Reader, name string) (io.ReadCloser, error) {
+	switch {
+	case strings.HasSuffix(name, compressionExtensions[CompressionGzip]):
+		return gzip.NewReader(r)
+	case strings.HasSuffix(name, compressionExtensions[CompressionZstd]):
+		dec, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	case strings.HasSuffix(name, compressionExtensions[CompressionXz]):
+		dec, err := xz.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(dec), nil
+	case strings.HasSuffix(name, compressionExtensions[CompressionNone]):
+		return io.NopCloser(r), nil
+	default:
+		return nil, fmt.Errorf("unknown archive format: %s", name)
+	}
+}
+
+// nopWriteCloser adds a no-op `Close()` to a writer.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+// Close does nothing.
+func (nopWriteCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,51 @@
+package mcsmanager
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompressedArchives(t *testing.T) {
+	// Create temp dir to test in
+	dir := t.TempDir()
+	if err := setupTestDir(dir); err != nil {
+		t.Fatalf("error creating test dir: %s\n", err)
+	}
+
+	for _, compression := range compressionLevels {
+		ext, err := ArchiveExt(compression)
+		if err != nil {
+			t.Fatalf("error getting archive extension: %s\n", err)
+		}
+
+		// Archive the directory tree
+		archivePath := filepath.Join(t.TempDir(), "archive"+ext)
+		file, err := os.Create(archivePath)
+		if err != nil {
+			t.Fatalf("error creating archive file: %s\n", err)
+		}
+		compressor, err := NewCompressor(file, compression, CompressionOptions{})
+		if err != nil {
+			t.Fatalf("error creating %s compressor: %s\n", compression, err)
+		}
+		w := tar.NewWriter(compressor)
+		if _, err = Archive(dir, w); err != nil {
+			t.Fatalf("error writing file tree to archive: %s\n", err)
+		}
+		w.Close()
+		compressor.Close()
+		file.Close()
+
+		// Extract it again
+		extractPath := t.TempDir()
+		if err = Extract(archivePath, extractPath); err != nil {
+			t.Fatalf("error extracting %s archive: %s\n", compression, err)
+		}
+
+		if err = verifyFiles(dir, extractPath); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
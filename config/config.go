@@ -56,11 +56,25 @@ func Default() Root {
 		},
 
 		BackupSettings: backupSettings{
+			Backend:       BackendArchive,
+			Compression:   "none",
 			BackupDir:     "backups",
 			ExcludedPaths: &[]string{},
 			MaxBackups:    10,
 			MaxAge:        7,
 		},
+
+		RestartSettings: restartSettings{
+			Warnings: []string{"5m", "1m", "30s", "10s"},
+			Message:  "Server restarting in {time}!",
+		},
+
+		Supervisor: supervisorSettings{
+			MinBackoff:  "10s",
+			MaxBackoff:  "5m",
+			MaxRestarts: 3,
+			HangTimeout: "60s",
+		},
 	}
 }
 
@@ -86,7 +100,7 @@ func Load(prefix string) (conf Root, err error) {
 func (c Root) Save(prefix string) error {
 	path := filepath.Join(prefix, "config.toml")
 
-	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, os.ModeAppend)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestSaveOverwrites(t *testing.T) {
+	dir := t.TempDir()
+	if err := CreateFile(dir); err != nil {
+		t.Fatalf("error creating config: %s\n", err)
+	}
+
+	conf := Default()
+	if err := conf.Save(dir); err != nil {
+		t.Fatalf("error saving config: %s\n", err)
+	}
+
+	// Saving again replaces the config instead of adding to it
+	conf.ServerSettings.ArgsFile = "libraries/unix_args.txt"
+	if err := conf.Save(dir); err != nil {
+		t.Fatalf("error saving config: %s\n", err)
+	}
+
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("error loading config: %s\n", err)
+	}
+	if loaded.ServerSettings.ArgsFile != conf.ServerSettings.ArgsFile {
+		t.Fatalf("expected args file '%s', got '%s'", conf.ServerSettings.ArgsFile, loaded.ServerSettings.ArgsFile)
+	}
+}
+
+func TestSetLimits(t *testing.T) {
+	conf := Default()
+	cases := map[string]struct {
+		set        BackupSet
+		maxBackups int
+		maxAge     int
+	}{
+		"set without limits": {BackupSet{Name: "plugins"}, 10, 7},
+		"set with limits":    {BackupSet{Name: "plugins", MaxBackups: 3, MaxAge: 30}, 3, 30},
+		"disabled limits":    {BackupSet{Name: "plugins", MaxBackups: -1, MaxAge: -1}, -1, -1},
+	}
+
+	for name, c := range cases {
+		maxBackups, maxAge := conf.BackupSettings.SetLimits(c.set)
+		if maxBackups != c.maxBackups || maxAge != c.maxAge {
+			t.Fatalf("%s: expected %d backups and %d days, got %d and %d", name, c.maxBackups, c.maxAge, maxBackups, maxAge)
+		}
+	}
+}
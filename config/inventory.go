@@ -0,0 +1,163 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// InventoryEnv is the environment variable that can be set to use an
+// inventory file in another place.
+const InventoryEnv = "MCSMANAGER_INVENTORY"
+
+// Inventory is the list of servers that mcsmanager manages, so they can be
+// picked by name instead of by their path.
+type Inventory struct {
+	Servers  map[string]*InventoryServer `toml:"servers"`
+	Networks map[string]*Network         `toml:"networks"`
+}
+
+// InventoryServer is a server in the inventory.
+type InventoryServer struct {
+	Name string `toml:"-"`
+	Path string `toml:"path"`
+}
+
+// Network is a proxy server and its backend servers, which are managed
+// together. Both are names of servers in the inventory.
+type Network struct {
+	Name     string   `toml:"-"`
+	Proxy    string   `toml:"proxy"`
+	Backends []string `toml:"backends"`
+}
+
+// InventoryPath returns the path to the inventory file, which is
+// `mcsmanager/servers.toml` in the user's config directory.
+func InventoryPath() (string, error) {
+	if path := os.Getenv(InventoryEnv); path != "" {
+		return path, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "mcsmanager", "servers.toml"), nil
+}
+
+// LoadInventory reads the inventory file. If there is no inventory file,
+// an empty inventory is returned.
+//
+// Relative server paths are relative to the directory of the inventory
+// file, and a leading `~` is replaced with the home directory.
+func LoadInventory() (*Inventory, error) {
+	path, err := InventoryPath()
+	if err != nil {
+		return nil, err
+	}
+
+	inv := &Inventory{}
+	if _, err = toml.DecodeFile(path, inv); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("unable to read inventory '%s': %s", path, err)
+	}
+	if inv.Servers == nil {
+		inv.Servers = make(map[string]*InventoryServer)
+	}
+
+	for name, server := range inv.Servers {
+		if server.Path == "" {
+			return nil, fmt.Errorf("server '%s' in the inventory has no path", name)
+		}
+
+		server.Name = name
+		if server.Path, err = expandPath(server.Path, filepath.Dir(path)); err != nil {
+			return nil, err
+		}
+	}
+
+	for name, network := range inv.Networks {
+		if network.Proxy == "" {
+			return nil, fmt.Errorf("network '%s' in the inventory has no proxy", name)
+		}
+
+		network.Name = name
+		for _, server := range append([]string{network.Proxy}, network.Backends...) {
+			if _, ok := inv.Servers[server]; !ok {
+				return nil, fmt.Errorf("network '%s' uses server '%s', which isn't in the inventory", name, server)
+			}
+		}
+	}
+
+	return inv, nil
+}
+
+// List returns all of the servers in the inventory, sorted by name.
+func (inv *Inventory) List() []*InventoryServer {
+	servers := make([]*InventoryServer, 0, len(inv.Servers))
+	for _, server := range inv.Servers {
+		servers = append(servers, server)
+	}
+
+	sort.Slice(servers, func(i, j int) bool {
+		return servers[i].Name < servers[j].Name
+	})
+
+	return servers
+}
+
+// Find looks for a server in the inventory by its name.
+func (inv *Inventory) Find(name string) (*InventoryServer, error) {
+	server, ok := inv.Servers[name]
+	if !ok {
+		return nil, fmt.Errorf("no server named '%s' in the inventory", name)
+	}
+
+	return server, nil
+}
+
+// expandPath makes a server path absolute.
+func expandPath(path, base string) (string, error) {
+	if path == "~" || strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, strings.TrimPrefix(path, "~"))
+	}
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(base, path)
+	}
+
+	return filepath.Clean(path), nil
+}
+
+// ListNetworks returns all of the networks in the inventory, sorted by name.
+func (inv *Inventory) ListNetworks() []*Network {
+	networks := make([]*Network, 0, len(inv.Networks))
+	for _, network := range inv.Networks {
+		networks = append(networks, network)
+	}
+
+	sort.Slice(networks, func(i, j int) bool {
+		return networks[i].Name < networks[j].Name
+	})
+
+	return networks
+}
+
+// FindNetwork looks for a network in the inventory by its name.
+func (inv *Inventory) FindNetwork(name string) (*Network, error) {
+	network, ok := inv.Networks[name]
+	if !ok {
+		return nil, fmt.Errorf("no network named '%s' in the inventory", name)
+	}
+
+	return network, nil
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testInventory = `
+[servers.survival]
+path = "/srv/minecraft/survival"
+
+[servers.creative]
+path = "creative"
+
+[servers.lobby]
+path = "~/lobby"
+`
+
+func writeInventory(t *testing.T, contents string) string {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "servers.toml")
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("error writing inventory: %s\n", err)
+	}
+	t.Setenv(InventoryEnv, path)
+
+	return dir
+}
+
+func TestLoadInventory(t *testing.T) {
+	dir := writeInventory(t, testInventory)
+	t.Setenv("HOME", "/home/steve")
+
+	inv, err := LoadInventory()
+	if err != nil {
+		t.Fatalf("error loading inventory: %s\n", err)
+	}
+
+	servers := inv.List()
+	expected := []InventoryServer{
+		{Name: "creative", Path: filepath.Join(dir, "creative")},
+		{Name: "lobby", Path: "/home/steve/lobby"},
+		{Name: "survival", Path: "/srv/minecraft/survival"},
+	}
+	if len(servers) != len(expected) {
+		t.Fatalf("expected %d servers, got %d", len(expected), len(servers))
+	}
+	for i, server := range servers {
+		if *server != expected[i] {
+			t.Fatalf("expected %+v, got %+v", expected[i], *server)
+		}
+	}
+
+	if _, err = inv.Find("survival"); err != nil {
+		t.Fatalf("error finding server: %s\n", err)
+	}
+	if _, err = inv.Find("skyblock"); err == nil {
+		t.Fatalf("expected an error for a missing server")
+	}
+}
+
+func TestLoadMissingInventory(t *testing.T) {
+	t.Setenv(InventoryEnv, filepath.Join(t.TempDir(), "servers.toml"))
+
+	inv, err := LoadInventory()
+	if err != nil {
+		t.Fatalf("error loading inventory: %s\n", err)
+	}
+	if len(inv.List()) != 0 {
+		t.Fatalf("expected an empty inventory")
+	}
+}
+
+func TestLoadInventoryWithoutPath(t *testing.T) {
+	writeInventory(t, "[servers.survival]\n")
+
+	if _, err := LoadInventory(); err == nil {
+		t.Fatalf("expected an error for a server without a path")
+	}
+}
+
+func TestLoadInventoryNetworks(t *testing.T) {
+	writeInventory(t, testInventory+`
+[servers.proxy]
+path = "proxy"
+
+[networks.main]
+proxy = "proxy"
+backends = ["lobby", "survival"]
+`)
+
+	inv, err := LoadInventory()
+	if err != nil {
+		t.Fatalf("error loading inventory: %s\n", err)
+	}
+
+	networks := inv.ListNetworks()
+	if len(networks) != 1 || networks[0].Name != "main" || len(networks[0].Backends) != 2 {
+		t.Fatalf("unexpected networks: %+v", networks)
+	}
+	if _, err = inv.FindNetwork("main"); err != nil {
+		t.Fatalf("error finding network: %s\n", err)
+	}
+	if _, err = inv.FindNetwork("other"); err == nil {
+		t.Fatalf("expected an error for a missing network")
+	}
+}
+
+func TestLoadInventoryNetworkUnknownServer(t *testing.T) {
+	writeInventory(t, testInventory+`
+[networks.main]
+proxy = "proxy"
+backends = ["lobby"]
+`)
+
+	if _, err := LoadInventory(); err == nil {
+		t.Fatalf("expected an error for a network with an unknown proxy")
+	}
+}
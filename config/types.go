@@ -1,11 +1,23 @@
 package config
 
+const (
+	// BackendArchive stores each backup as a tar archive.
+	BackendArchive = "archive"
+
+	// BackendRepository stores backups in a content-addressed repository,
+	// where files that haven't changed are only stored once.
+	BackendRepository = "repository"
+)
+
 // Root is the root-level of our server configuration structure.
 type Root struct {
-	MainSettings   mainSettings   `toml:"main_settings"`
-	JavaSettings   javaSettings   `toml:"java_settings"`
-	ServerSettings serverSettings `toml:"server_settings"`
-	BackupSettings backupSettings `toml:"backup_settings"`
+	MainSettings    mainSettings       `toml:"main_settings"`
+	JavaSettings    javaSettings       `toml:"java_settings"`
+	ServerSettings  serverSettings     `toml:"server_settings"`
+	BackupSettings  backupSettings     `toml:"backup_settings"`
+	RestartSettings restartSettings    `toml:"restart_settings"`
+	Supervisor      supervisorSettings `toml:"supervisor"`
+	Schedule        scheduleSettings   `toml:"schedule"`
 }
 
 type mainSettings struct {
@@ -22,12 +34,100 @@ type javaSettings struct {
 }
 
 type serverSettings struct {
-	Flags *[]string `toml:"jar_flags"`
+	Flags    *[]string `toml:"jar_flags"`
+	ArgsFile string    `toml:"args_file,omitempty" comment:"Java argument file to launch the server with instead of the server jar, relative to the server directory. Set by the forge and neoforge providers"`
 }
 
 type backupSettings struct {
-	BackupDir     string    `toml:"backup_dir" comment:"Path can be an absolute or relative path"`
-	ExcludedPaths *[]string `toml:"excluded_paths" comment:"Files that have any of these in their path will not be archived. The backup directory is always excluded"`
-	MaxBackups    int       `toml:"max_number_backups"`
-	MaxAge        int       `toml:"days_to_keep"`
+	Backend            string           `toml:"backend" comment:"How backups are stored; \"archive\" for tar archives, or \"repository\" for a deduplicated backup repository"`
+	Compression        string           `toml:"compression" comment:"Compression format for backup archives; \"none\", \"gzip\", \"zstd\", or \"xz\""`
+	CompressionLevel   int              `toml:"compression_level" comment:"Compression level; 1-9 for gzip, 1-22 for zstd. 0 uses the default for the format"`
+	CompressionThreads int              `toml:"compression_threads" comment:"Number of threads zstd may use. 0 uses one thread per CPU"`
+	BackupDir          string           `toml:"backup_dir" comment:"Path can be an absolute or relative path"`
+	ExcludedPaths      *[]string        `toml:"excluded_paths" comment:"Gitignore-style patterns for files that will not be archived, e.g. \"*.log\" or \"/plugins/dynmap/web/\". More patterns can be put in a .mcsignore file in the server directory. The backup directory is always excluded"`
+	MaxBackups         int              `toml:"max_number_backups"`
+	MaxAge             int              `toml:"days_to_keep"`
+	Targets            []TargetSettings `toml:"targets" comment:"Other places to copy backup archives to, such as another disk or an S3 bucket"`
+	Sets               []BackupSet      `toml:"sets" comment:"Parts of the server that can be backed up on their own with 'backup --set <name>'"`
+}
+
+// BackupSet is a named part of the server that is backed up on its own,
+// with its own limits on how many backups to keep.
+type BackupSet struct {
+	Name       string   `toml:"name"`
+	Worlds     bool     `toml:"worlds" comment:"Include the world directories, found using the level-name in server.properties"`
+	Paths      []string `toml:"paths" comment:"Paths relative to the server directory to include, which may contain wildcards, e.g. \"plugins\" or \"*.properties\""`
+	MaxBackups int      `toml:"max_number_backups"`
+	MaxAge     int      `toml:"days_to_keep"`
+}
+
+// SetLimits returns how many backups of a set to keep, and for how many
+// days. A limit that the set leaves out falls back to the limit for all
+// backups.
+func (b backupSettings) SetLimits(set BackupSet) (maxBackups, maxAge int) {
+	maxBackups, maxAge = set.MaxBackups, set.MaxAge
+	if maxBackups == 0 {
+		maxBackups = b.MaxBackups
+	}
+	if maxAge == 0 {
+		maxAge = b.MaxAge
+	}
+
+	return
+}
+
+// TargetSettings holds the settings for a place that backups are copied to.
+type TargetSettings struct {
+	Name      string `toml:"name"`
+	Type      string `toml:"type" comment:"Either \"local\" or \"s3\""`
+	Path      string `toml:"path,omitempty" comment:"Directory to copy backups to for local targets"`
+	Endpoint  string `toml:"endpoint,omitempty" comment:"URL of the S3-compatible service, e.g. \"https://s3.us-east-1.amazonaws.com\""`
+	Bucket    string `toml:"bucket,omitempty"`
+	Region    string `toml:"region,omitempty"`
+	Prefix    string `toml:"prefix,omitempty" comment:"Prefix to add to the name of every uploaded backup, e.g. \"server1/\""`
+	AccessKey string `toml:"access_key,omitempty"`
+	SecretKey string `toml:"secret_key,omitempty"`
+}
+
+type restartSettings struct {
+	Warnings []string `toml:"warnings" comment:"When to warn players before a restart, e.g. [\"5m\", \"1m\", \"10s\"]"`
+	Message  string   `toml:"message" comment:"Warning message to send with 'say'. {time} is replaced with the time left"`
+	Title    bool     `toml:"title" comment:"Also show the warnings as a title on the players' screens"`
+}
+
+type supervisorSettings struct {
+	Enabled     bool   `toml:"enabled" comment:"Also watch for crashes while running 'mcsmanager daemon'"`
+	MinBackoff  string `toml:"min_backoff" comment:"How long to wait before restarting after the first crash. Doubles with every crash in the last hour"`
+	MaxBackoff  string `toml:"max_backoff" comment:"The longest to wait before restarting after a crash"`
+	MaxRestarts int    `toml:"max_restarts_per_hour" comment:"Stop restarting the server after this many crashes in an hour"`
+	HangTimeout string `toml:"hang_timeout" comment:"How long the server may stay unresponsive after the watchdog complains before it is killed"`
+}
+
+type scheduleSettings struct {
+	Backups  []ScheduledBackup  `toml:"backups" comment:"Backups to run with 'mcsmanager daemon'"`
+	Restarts []ScheduledRestart `toml:"restarts" comment:"Server restarts to run with 'mcsmanager daemon'"`
+	Commands []ScheduledCommand `toml:"commands" comment:"Console commands to run with 'mcsmanager daemon'"`
+}
+
+// ScheduledBackup is a backup that the daemon runs on a schedule.
+type ScheduledBackup struct {
+	Cron   string `toml:"cron" comment:"Cron expression for when to run, e.g. \"0 */6 * * *\" for every six hours"`
+	Online bool   `toml:"online" comment:"Back up the server while it is running instead of skipping the backup"`
+	World  string `toml:"world,omitempty" comment:"Only back up the world with this name"`
+	Set    string `toml:"set,omitempty" comment:"Only back up the backup set with this name"`
+}
+
+// ScheduledRestart is a server restart that the daemon runs on a schedule.
+type ScheduledRestart struct {
+	Cron     string   `toml:"cron" comment:"Cron expression for when to restart, e.g. \"0 4 * * *\" for 4 AM every day"`
+	Warnings []string `toml:"warnings,omitempty" comment:"How long before the restart to warn players. Uses the warnings from the restart settings if not set"`
+	Message  string   `toml:"message,omitempty" comment:"Warning message to send with 'say'. Uses the message from the restart settings if not set"`
+	Backup   bool     `toml:"backup" comment:"Back up the server while it is stopped"`
+}
+
+// ScheduledCommand is a list of console commands that the daemon runs
+// on a schedule.
+type ScheduledCommand struct {
+	Cron     string   `toml:"cron"`
+	Commands []string `toml:"commands" comment:"Console commands to run, e.g. [\"say Remember to vote!\"]"`
 }
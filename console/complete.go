@@ -0,0 +1,131 @@
+package console
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Commands are the console commands of vanilla and Paper servers that
+// can be tab completed.
+var Commands = []string{
+	// Vanilla
+	"advancement", "attribute", "ban", "ban-ip", "banlist", "bossbar",
+	"clear", "clone", "damage", "data", "datapack", "debug",
+	"defaultgamemode", "deop", "difficulty", "effect", "enchant", "execute",
+	"experience", "fill", "fillbiome", "forceload", "function", "gamemode",
+	"gamerule", "give", "help", "item", "jfr", "kick", "kill", "list",
+	"locate", "loot", "me", "msg", "op", "pardon", "pardon-ip", "particle",
+	"perf", "place", "playsound", "recipe", "reload", "ride", "save-all",
+	"save-off", "save-on", "say", "schedule", "scoreboard", "seed",
+	"setblock", "setidletimeout", "setworldspawn", "spawnpoint", "spectate",
+	"spreadplayers", "stop", "stopsound", "summon", "tag", "team",
+	"teammsg", "teleport", "tell", "tellraw", "tick", "time", "title",
+	"tm", "tp", "transfer", "trigger", "w", "weather", "whitelist",
+	"worldborder", "xp",
+
+	// Paper and Bukkit
+	"mspt", "paper", "plugins", "pl", "restart", "spigot", "timings",
+	"tps", "version", "ver",
+}
+
+// colorCode matches the formatting codes in a server's replies.
+var colorCode = regexp.MustCompile("§[0-9a-fk-orx]")
+
+// playerList matches the reply to the `list` command.
+var playerList = regexp.MustCompile(`players online:\s*(.*)`)
+
+// Completer tab completes console commands, and the names of the players
+// that are online for their arguments.
+type Completer struct {
+	Commands []string
+	Players  func() []string
+}
+
+// Complete completes the word in front of the cursor. If more than one
+// completion matches, the word is completed as far as they agree.
+func (c Completer) Complete(line string, pos int) (string, int, bool) {
+	start := strings.LastIndex(line[:pos], " ") + 1
+	word := line[start:pos]
+
+	var candidates []string
+	if start == 0 {
+		// Allow the slash that players use in the chat
+		slash := strings.HasPrefix(word, "/")
+		word = strings.TrimPrefix(word, "/")
+		candidates = c.Commands
+		if slash {
+			start++
+		}
+	} else if c.Players != nil {
+		candidates = c.Players()
+	}
+
+	matches := matching(candidates, word)
+	if len(matches) == 0 {
+		return "", 0, false
+	}
+
+	completion := commonPrefix(matches)
+	rest := line[pos:]
+	if len(matches) == 1 {
+		completion += " "
+		rest = strings.TrimLeft(rest, " ")
+	}
+	if len(completion) < len(word) || completion == word {
+		return "", 0, false
+	}
+
+	newLine := line[:start] + completion + rest
+	return newLine, start + len(completion), true
+}
+
+// ParsePlayers returns the names of the players in the reply to the
+// `list` command.
+func ParsePlayers(reply string) []string {
+	match := playerList.FindStringSubmatch(StripColors(reply))
+	if match == nil {
+		return []string{}
+	}
+
+	players := make([]string, 0)
+	for _, name := range strings.Split(match[1], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			players = append(players, name)
+		}
+	}
+
+	return players
+}
+
+// StripColors removes the formatting codes from a server's reply.
+func StripColors(s string) string {
+	return colorCode.ReplaceAllString(s, "")
+}
+
+// matching returns the sorted, unique candidates that start with a prefix.
+func matching(candidates []string, prefix string) []string {
+	seen := make(map[string]bool)
+	matches := make([]string, 0)
+	for _, candidate := range candidates {
+		if strings.HasPrefix(strings.ToLower(candidate), strings.ToLower(prefix)) && !seen[candidate] {
+			seen[candidate] = true
+			matches = append(matches, candidate)
+		}
+	}
+	sort.Strings(matches)
+
+	return matches
+}
+
+// commonPrefix returns the longest prefix that all of the words share.
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+
+	return prefix
+}
@@ -0,0 +1,68 @@
+package console
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestComplete(t *testing.T) {
+	completer := Completer{
+		Commands: []string{"say", "save-all", "save-off", "save-on", "stop", "kick"},
+		Players: func() []string {
+			return []string{"Alice", "Albert", "Bob"}
+		},
+	}
+
+	cases := []struct {
+		line, expected string
+		pos            int
+		ok             bool
+	}{
+		{"st", "stop ", 0, true},
+		{"/st", "/stop ", 0, true},
+		{"sa", "sa", 0, false},
+		{"save", "save-", 0, true},
+		{"save-a", "save-all ", 0, true},
+		{"kick b", "kick Bob ", 0, true},
+		{"kick al", "kick Al", 0, true},
+		{"kick Alb", "kick Albert ", 0, true},
+		{"kick x", "kick x", 0, false},
+		{"", "", 0, false},
+	}
+
+	for _, c := range cases {
+		line, pos, ok := completer.Complete(c.line, len(c.line))
+		if ok != c.ok {
+			t.Fatalf("'%s': expected ok to be %v", c.line, c.ok)
+		}
+		if ok && (line != c.expected || pos != len(c.expected)) {
+			t.Fatalf("'%s': expected '%s' at %d, got '%s' at %d", c.line, c.expected, len(c.expected), line, pos)
+		}
+	}
+}
+
+func TestCompleteMiddle(t *testing.T) {
+	completer := Completer{Commands: []string{"kick"}, Players: func() []string {
+		return []string{"Alice"}
+	}}
+
+	line, pos, ok := completer.Complete("ki Alice", 2)
+	if !ok || line != "kick Alice" || pos != 5 {
+		t.Fatalf("expected 'kick Alice' at 5, got '%s' at %d", line, pos)
+	}
+}
+
+func TestParsePlayers(t *testing.T) {
+	cases := map[string][]string{
+		"There are 0 of a max of 20 players online: ":           {},
+		"There are 2 of a max of 20 players online: Alice, Bob": {"Alice", "Bob"},
+		"There are 1 of a max of 20 players online: §eAlice§r":  {"Alice"},
+		"Unknown command": {},
+	}
+
+	for reply, expected := range cases {
+		if players := ParsePlayers(reply); !reflect.DeepEqual(players, expected) {
+			t.Fatalf("'%s': expected %v, got %v", reply, expected, players)
+		}
+	}
+}
@@ -2,6 +2,9 @@ package mcsmanager
 
 import (
 	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
@@ -20,19 +23,28 @@ import (
 // file tree starting from the path that is passed in. This means we
 // don't have to do a bunch of extra recursive logic for nested directories
 // and having different code paths for files and directories.
-func Archive(path string, w *tar.Writer, exclusions ...string) error {
+//
+// The returned manifest holds the checksum of every file that was
+// archived, so the archive can be verified later.
+func Archive(path string, w *tar.Writer, exclusions ...string) (*Manifest, error) {
 	dir := os.DirFS(path)
+	manifest := NewManifest()
+
+	matcher, err := LoadExclusions(path, exclusions...)
+	if err != nil {
+		return nil, fmt.Errorf("error reading exclusions: %s", err)
+	}
 
 	// Count the number of files to archive
 	count, err := CountFiles(path, exclusions...)
 	if err != nil {
-		return fmt.Errorf("error counting files: %s", err)
+		return nil, fmt.Errorf("error counting files: %s", err)
 	}
 
 	bar := pb.New(count)
 	bar.SetTemplate(pb.Simple)
 	bar.Set(pb.CleanOnFinish, true)
-	bar.SetWriter(os.Stdout)
+	bar.SetWriter(os.Stderr)
 	bar.SetMaxWidth(80)
 	bar.Start()
 
@@ -44,7 +56,10 @@ func Archive(path string, w *tar.Writer, exclusions ...string) error {
 		}
 
 		// Don't archive files or directories that should be excluded
-		if isExempt(child, exclusions...) {
+		if matcher.Match(child, entry.IsDir()) {
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
 			return nil
 		}
 
@@ -73,9 +88,17 @@ func Archive(path string, w *tar.Writer, exclusions ...string) error {
 			}
 			defer file.Close()
 
-			if _, err = io.Copy(w, file); err != nil {
+			// Hash the file while we copy it. Only copy the size in the
+			// header, since files of a running server, like the logs,
+			// can keep growing while they are archived.
+			hash := sha256.New()
+			if _, err = io.CopyN(w, io.TeeReader(file, hash), header.Size); err != nil {
+				if errors.Is(err, io.EOF) {
+					return fmt.Errorf("'%s' got smaller while it was archived", name)
+				}
 				return err
 			}
+			manifest.Files[name] = ManifestEntry{Size: header.Size, Hash: hex.EncodeToString(hash.Sum(nil))}
 
 			// Print our progress
 			bar.Increment()
@@ -85,16 +108,31 @@ func Archive(path string, w *tar.Writer, exclusions ...string) error {
 	})
 
 	bar.Finish()
-	return err
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
 }
 
 // CountFiles walks a directory tree and counts all files present.
 func CountFiles(path string, exclusions ...string) (count int, err error) {
+	matcher, err := LoadExclusions(path, exclusions...)
+	if err != nil {
+		return
+	}
+
 	err = fs.WalkDir(os.DirFS(path), ".", func(child string, dir fs.DirEntry, walkErr error) error {
 		if walkErr != nil {
-			return err
+			return walkErr
 		}
-		if isExempt(child, exclusions...) {
+		if child == "." {
+			return nil
+		}
+		if matcher.Match(child, dir.IsDir()) {
+			if dir.IsDir() {
+				return fs.SkipDir
+			}
 			return nil
 		}
 		if !dir.IsDir() {
@@ -106,6 +144,27 @@ func CountFiles(path string, exclusions ...string) (count int, err error) {
 	return
 }
 
+// DirSize walks a directory tree and adds up the size of all files in it.
+func DirSize(path string) (size int64, err error) {
+	err = filepath.WalkDir(path, func(child string, dir fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if dir.IsDir() {
+			return nil
+		}
+
+		info, err := dir.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+
+	return
+}
+
 // PruneOld will delete files in the given directory if they were last modified
 // after a certain period of time.
 func PruneOld(path string, maxAge int, exemptions ...string) (total int, err error) {
@@ -130,10 +189,11 @@ func PruneOld(path string, maxAge int, exemptions ...string) (total int, err err
 	}
 
 	cur := time.Now()
+	matcher := NewMatcher(exemptions...)
 
 	// Iterate over the files
 	for _, file := range files {
-		if isExempt(file.Name(), exemptions...) {
+		if matcher.Match(file.Name(), file.IsDir()) {
 			continue
 		}
 
@@ -154,7 +214,7 @@ func PruneOld(path string, maxAge int, exemptions ...string) (total int, err err
 // Prune will remove the oldest files in a directory until
 // the number of files in the directory is one under the limit.
 func Prune(path string, maxFiles int, exemptions ...string) (total int, err error) {
-	if maxFiles == -1 { // -1 to disable pruning
+	if maxFiles <= 0 { // -1 or 0 to disable pruning
 		return
 	}
 
@@ -172,11 +232,14 @@ func Prune(path string, maxFiles int, exemptions ...string) (total int, err erro
 	}
 
 	// Check for exempt files
-	for i, file := range files {
-		if isExempt(file.Name(), exemptions...) {
-			files = append(files[:i], files[i+1:]...)
+	matcher := NewMatcher(exemptions...)
+	kept := make([]os.FileInfo, 0, len(files))
+	for _, file := range files {
+		if !matcher.Match(file.Name(), file.IsDir()) {
+			kept = append(kept, file)
 		}
 	}
+	files = kept
 
 	// Check if there are too many files
 	numFiles := len(files)
@@ -207,14 +270,13 @@ func Prune(path string, maxFiles int, exemptions ...string) (total int, err erro
 	return
 }
 
-// isExempt checks if a given file is in the list
-// of exempt files, returning `true` or `false`.
-func isExempt(path string, exemptions ...string) bool {
-	for _, exemption := range exemptions {
-		if strings.Contains(path, exemption) {
-			return true
-		}
+// LoadExclusions creates a matcher for the given exclusion patterns, along
+// with any patterns in the ignore file in the given directory.
+func LoadExclusions(path string, exclusions ...string) (*Matcher, error) {
+	patterns, err := LoadIgnoreFile(path)
+	if err != nil {
+		return nil, err
 	}
 
-	return false
+	return NewMatcher(append(append([]string{}, exclusions...), patterns...)...), nil
 }
@@ -2,6 +2,7 @@ package mcsmanager
 
 import (
 	"archive/tar"
+	"bytes"
 	"fmt"
 	"io"
 	"os"
@@ -50,7 +51,7 @@ func TestArchiveDir(t *testing.T) {
 	w := tar.NewWriter(tarFile)
 
 	// Archive the directory tree
-	err = Archive(dir, w, "archive.tar")
+	_, err = Archive(dir, w, "archive.tar")
 	if err != nil {
 		t.Fatalf("error writing file tree to archive: %s\n", err)
 	}
@@ -72,6 +73,63 @@ func TestArchiveDir(t *testing.T) {
 	}
 }
 
+// growingWriter appends to a file the first time something is written,
+// like a log that grows after its header is written to an archive.
+type growingWriter struct {
+	io.Writer
+	path  string
+	grown bool
+}
+
+func (g *growingWriter) Write(p []byte) (int, error) {
+	if !g.grown {
+		g.grown = true
+		file, err := os.OpenFile(g.path, os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return 0, err
+		}
+		defer file.Close()
+		if _, err = file.WriteString("more log lines\n"); err != nil {
+			return 0, err
+		}
+	}
+
+	return g.Writer.Write(p)
+}
+
+func TestArchiveGrowingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "latest.log")
+	contents := "first log line\n"
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("error creating test file: %s\n", err)
+	}
+
+	var buf bytes.Buffer
+	w := tar.NewWriter(&growingWriter{Writer: &buf, path: path})
+	manifest, err := Archive(dir, w)
+	if err != nil {
+		t.Fatalf("error writing file tree to archive: %s\n", err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatalf("error closing archive: %s\n", err)
+	}
+
+	// Only the contents from when the header was written are archived
+	if size := manifest.Files["latest.log"].Size; size != int64(len(contents)) {
+		t.Fatalf("expected a size of %d in the manifest, got %d", len(contents), size)
+	}
+
+	r := tar.NewReader(&buf)
+	if _, err = r.Next(); err != nil {
+		t.Fatalf("error reading archive: %s\n", err)
+	}
+	raw, err := io.ReadAll(r)
+	if err != nil || string(raw) != contents {
+		t.Fatalf("expected '%s' in the archive, got '%s' (%v)", contents, raw, err)
+	}
+}
+
 // extract extracts the entire file tree inside a tar archive to the given path.
 func extract(archive, dir string) error {
 	tarFile, err := os.Open(archive)
@@ -205,6 +263,25 @@ func TestCountFilesWithExclusions(t *testing.T) {
 	}
 }
 
+func TestDirSize(t *testing.T) {
+	// Create temp dir to test in
+	dir := t.TempDir()
+	if err := setupTestDir(dir); err != nil {
+		t.Fatalf("error creating test dir: %s\n", err)
+	}
+
+	size, err := DirSize(dir)
+	if err != nil {
+		t.Fatalf("error getting dir size: %s\n", err)
+	}
+
+	// Check if the result is correct
+	expected := int64(len(files) * len("test file contents"))
+	if size != expected {
+		t.Fatalf("wrong size: expected %d, actual: %d", expected, size)
+	}
+}
+
 func TestPruneFiles(t *testing.T) {
 	// Create temp dir to test in
 	dir := t.TempDir()
@@ -254,7 +331,7 @@ func TestPruneFilesWithExemptions(t *testing.T) {
 		f.Close()
 	}
 
-	result, err := Prune(dir, 2, ".txt")
+	result, err := Prune(dir, 2, "*.txt")
 	if err != nil {
 		t.Fatalf("error counting files: %s\n", err)
 	}
@@ -302,7 +379,7 @@ func TestPruneOldFilesWithExemptions(t *testing.T) {
 	f.Close()
 
 	// Prune the files
-	result, err := PruneOld(dir, 0, ".txt")
+	result, err := PruneOld(dir, 0, "*.txt")
 	if err != nil {
 		t.Fatalf("error counting files: %s\n", err)
 	}
@@ -13,6 +13,13 @@ require (
 	github.com/stretchr/testify v1.4.0 // indirect
 )
 
+require (
+	github.com/klauspost/compress v1.15.15
+	github.com/ulikunitz/xz v0.5.11
+	golang.org/x/term v0.1.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
 require (
 	github.com/DataDrake/flair v0.5.1 // indirect
 	github.com/VividCortex/ewma v1.1.1 // indirect
@@ -0,0 +1,242 @@
+package mcsmanager
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFile is the name of an optional file in the server root that
+// holds more exclusion patterns, one per line.
+const IgnoreFile = ".mcsignore"
+
+// Matcher checks paths against a list of gitignore-style patterns.
+//
+// Patterns support `*`, `?`, `[...]`, and `**` wildcards. A pattern that
+// starts with `!` re-includes paths that an earlier pattern excluded, and
+// a pattern that ends with `/` only matches directories. Patterns without
+// a slash match a file or directory name at any depth, while patterns with
+// a slash are anchored to the root. As with git, the last matching pattern
+// wins, and nothing inside of an excluded directory can be re-included.
+type Matcher struct {
+	patterns []pattern
+}
+
+// pattern is a single compiled exclusion pattern.
+type pattern struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// NewMatcher compiles a list of patterns. Blank patterns and
+// comments starting with `#` are ignored.
+func NewMatcher(patterns ...string) *Matcher {
+	m := &Matcher{patterns: make([]pattern, 0, len(patterns))}
+	for _, raw := range patterns {
+		if p, ok := compilePattern(raw); ok {
+			m.patterns = append(m.patterns, p)
+		}
+	}
+
+	return m
+}
+
+// LoadIgnoreFile reads the exclusion patterns from the ignore file in
+// the given directory. If there is no ignore file, no patterns and no
+// error are returned.
+func LoadIgnoreFile(dir string) ([]string, error) {
+	file, err := os.Open(filepath.Join(dir, IgnoreFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	patterns := make([]string, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		patterns = append(patterns, scanner.Text())
+	}
+
+	return patterns, scanner.Err()
+}
+
+// Match checks if a path relative to the root should be excluded. Paths
+// are expected to use forward slashes, as given by `fs.WalkDir()`.
+func (m *Matcher) Match(path string, isDir bool) bool {
+	if len(m.patterns) == 0 {
+		return false
+	}
+
+	path = strings.Trim(filepath.ToSlash(path), "/")
+
+	// Anything inside of an excluded directory is excluded
+	for i, c := range path {
+		if c == '/' && m.matchOne(path[:i], true) {
+			return true
+		}
+	}
+
+	return m.matchOne(path, isDir)
+}
+
+// matchOne checks a single path against every pattern, without
+// looking at its parent directories.
+func (m *Matcher) matchOne(path string, isDir bool) (excluded bool) {
+	for _, p := range m.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		if p.re.MatchString(path) {
+			excluded = !p.negate
+		}
+	}
+
+	return
+}
+
+// compilePattern turns a gitignore-style pattern into a regular expression.
+func compilePattern(raw string) (p pattern, ok bool) {
+	raw = strings.TrimRight(raw, " \t\r")
+	if raw == "" || strings.HasPrefix(raw, "#") {
+		return
+	}
+
+	if strings.HasPrefix(raw, "!") {
+		p.negate = true
+		raw = raw[1:]
+	} else if strings.HasPrefix(raw, `\`) {
+		raw = raw[1:]
+	}
+
+	if strings.HasSuffix(raw, "/") {
+		p.dirOnly = true
+		raw = strings.TrimRight(raw, "/")
+	}
+	if raw == "" {
+		return
+	}
+
+	// Patterns without a slash can match at any depth
+	anchored := strings.Contains(raw, "/")
+	raw = strings.TrimPrefix(raw, "/")
+	if !anchored {
+		raw = "**/" + raw
+	}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case strings.HasPrefix(raw[i:], "**/"):
+			// Zero or more leading directories
+			expr.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(raw[i:], "**") && i+2 == len(raw):
+			// Everything inside of a directory
+			expr.WriteString(".*")
+			i++
+		case c == '*':
+			expr.WriteString("[^/]*")
+		case c == '?':
+			expr.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(raw[i+1:], ']')
+			if end == -1 {
+				expr.WriteString(`\[`)
+				continue
+			}
+			class := raw[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(raw):
+			i++
+			expr.WriteString(regexp.QuoteMeta(string(raw[i])))
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return
+	}
+	p.re = re
+
+	return p, true
+}
+
+// SelectPaths returns exclusion patterns that exclude everything except the
+// given paths, which are relative to the root and may contain wildcards.
+//
+// Like with a gitignore, each parent directory of a path is re-included and
+// its other contents excluded, one level at a time. Paths inside of another
+// given path are dropped, since they would exclude the rest of it.
+func SelectPaths(paths ...string) []string {
+	cleaned := make([]string, 0, len(paths))
+	for _, path := range paths {
+		path = strings.Trim(filepath.ToSlash(filepath.Clean(path)), "/")
+		if path == "" || path == "." {
+			// Selecting the root selects everything
+			return []string{}
+		}
+		cleaned = append(cleaned, path)
+	}
+
+	// Split the paths into their components, without nested paths
+	selected := make([][]string, 0, len(cleaned))
+	depth := 0
+	for _, path := range cleaned {
+		nested := false
+		for _, other := range cleaned {
+			if strings.HasPrefix(path, other+"/") {
+				nested = true
+				break
+			}
+		}
+		if nested {
+			continue
+		}
+
+		parts := strings.Split(path, "/")
+		if len(parts) > depth {
+			depth = len(parts)
+		}
+		selected = append(selected, parts)
+	}
+
+	// Exclude and re-include one level of directories at a time
+	patterns := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(p string) {
+		if !seen[p] {
+			seen[p] = true
+			patterns = append(patterns, p)
+		}
+	}
+	for level := 1; level <= depth; level++ {
+		for _, parts := range selected {
+			if len(parts) >= level {
+				add("/" + strings.Join(append(parts[:level-1:level-1], "*"), "/"))
+			}
+		}
+		for _, parts := range selected {
+			if len(parts) >= level {
+				add("!/" + strings.Join(parts[:level], "/"))
+			}
+		}
+	}
+
+	return patterns
+}
@@ -0,0 +1,118 @@
+package mcsmanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatcher(t *testing.T) {
+	patterns := []string{
+		"# comment",
+		"cache",
+		"*.log",
+		"/backups",
+		"logs/",
+		"world/**/*.old",
+		"plugins/*/data/",
+		"!plugins/Keep/data/",
+		"!important.log",
+	}
+	m := NewMatcher(patterns...)
+
+	cases := []struct {
+		path     string
+		isDir    bool
+		excluded bool
+	}{
+		{"cache", true, true},
+		{"plugins/SomePlugin/cache", true, true},
+		{"plugins/SomePlugin/cache/file.bin", false, true},
+		{"plugins/SomePlugin/cachedconfig.yml", false, false},
+		{"server.log", false, true},
+		{"important.log", false, false},
+		{"plugins/Foo/debug.log", false, true},
+		{"backups", true, true},
+		{"backups/2021.tar", false, true},
+		{"plugins/backups", true, false},
+		{"logs", true, true},
+		{"logs/latest.log", false, true},
+		{"world/region/r.0.0.mca", false, false},
+		{"world/region/r.0.0.old", false, true},
+		{"world/level.old", false, true},
+		{"plugins/Foo/data", true, true},
+		{"plugins/Foo/data/users.yml", false, true},
+		{"plugins/Keep/data", true, false},
+		{"plugins/Keep/data/users.yml", false, false},
+		{"server.properties", false, false},
+	}
+
+	for _, c := range cases {
+		if actual := m.Match(c.path, c.isDir); actual != c.excluded {
+			t.Errorf("wrong match for '%s': expected %t, got %t", c.path, c.excluded, actual)
+		}
+	}
+}
+
+func TestMatcherExcludedParent(t *testing.T) {
+	m := NewMatcher("plugins/", "!plugins/keep.yml")
+
+	if !m.Match("plugins/keep.yml", false) {
+		t.Fatal("files inside of an excluded directory should not be re-included")
+	}
+}
+
+func TestCountFilesWithIgnoreFile(t *testing.T) {
+	// Create temp dir to test in
+	dir := t.TempDir()
+	if err := setupTestDir(dir); err != nil {
+		t.Fatalf("error creating test dir: %s\n", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, IgnoreFile), []byte("nested/\n.mcsignore\n"), 0644); err != nil {
+		t.Fatalf("error creating ignore file: %s\n", err)
+	}
+
+	result, err := CountFiles(dir)
+	if err != nil {
+		t.Fatalf("error counting files: %s\n", err)
+	}
+
+	// Check if the result is correct
+	if result != 2 {
+		t.Fatalf("counted the wrong number of files: expected 2, actual: %d", result)
+	}
+}
+
+func TestSelectPaths(t *testing.T) {
+	m := NewMatcher(SelectPaths("world", "plugins/Essentials", "plugins/*.jar", "world/region", "*.properties")...)
+
+	cases := []struct {
+		path     string
+		isDir    bool
+		excluded bool
+	}{
+		{"world", true, false},
+		{"world/region/r.0.0.mca", false, false},
+		{"world/level.dat", false, false},
+		{"world_nether", true, true},
+		{"plugins", true, false},
+		{"plugins/Essentials/config.yml", false, false},
+		{"plugins/Essentials.jar", false, false},
+		{"plugins/dynmap", true, true},
+		{"plugins/dynmap/web/index.html", false, true},
+		{"server.properties", false, false},
+		{"logs/latest.log", false, true},
+	}
+
+	for _, c := range cases {
+		if actual := m.Match(c.path, c.isDir); actual != c.excluded {
+			t.Errorf("wrong match for '%s': expected %t, got %t", c.path, c.excluded, actual)
+		}
+	}
+}
+
+func TestSelectPathsRoot(t *testing.T) {
+	if patterns := SelectPaths("world", "."); len(patterns) != 0 {
+		t.Fatalf("selecting the root should not exclude anything, got %v", patterns)
+	}
+}
@@ -0,0 +1,68 @@
+package mcsmanager
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ErrUnknownVersion is returned when the version of a server jar can't
+// be found.
+var ErrUnknownVersion = errors.New("unable to find the version of the server jar")
+
+// JarVersion reads the Minecraft version that a server jar is for, e.g.
+// "1.20.4". Vanilla jars have a `version.json` file, and Paperclip jars
+// list the jars that they contain in `META-INF/versions.list`.
+func JarVersion(path string) (string, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	for _, file := range r.File {
+		switch file.Name {
+		case "version.json":
+			rc, err := file.Open()
+			if err != nil {
+				return "", err
+			}
+			defer rc.Close()
+
+			var version struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			}
+			if err = json.NewDecoder(rc).Decode(&version); err != nil {
+				return "", err
+			}
+			if version.ID != "" {
+				return version.ID, nil
+			}
+			if version.Name != "" {
+				return version.Name, nil
+			}
+		case "META-INF/versions.list":
+			rc, err := file.Open()
+			if err != nil {
+				return "", err
+			}
+			defer rc.Close()
+
+			// Each line is a hash, an ID like "paper-1.20.4", and a path
+			scanner := bufio.NewScanner(rc)
+			for scanner.Scan() {
+				fields := strings.Fields(scanner.Text())
+				if len(fields) < 2 {
+					continue
+				}
+				id := fields[1]
+				return id[strings.LastIndex(id, "-")+1:], nil
+			}
+		}
+	}
+
+	return "", ErrUnknownVersion
+}
@@ -0,0 +1,55 @@
+package mcsmanager
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// createJar creates a jar file with the given files in it.
+func createJar(t *testing.T, files map[string]string) string {
+	path := filepath.Join(t.TempDir(), "server.jar")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("error creating jar: %s\n", err)
+	}
+	defer file.Close()
+
+	w := zip.NewWriter(file)
+	for name, contents := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatalf("error creating jar: %s\n", err)
+		}
+		f.Write([]byte(contents))
+	}
+	if err = w.Close(); err != nil {
+		t.Fatalf("error creating jar: %s\n", err)
+	}
+
+	return path
+}
+
+func TestJarVersion(t *testing.T) {
+	cases := map[string]map[string]string{
+		"1.20.4": {"version.json": `{"id": "1.20.4", "name": "1.20.4", "world_version": 3700}`},
+		"1.19.2": {"META-INF/versions.list": "abc123\tpaper-1.19.2\tpaper-1.19.2.jar\n"},
+	}
+
+	for expected, files := range cases {
+		version, err := JarVersion(createJar(t, files))
+		if err != nil {
+			t.Fatalf("error reading version: %s\n", err)
+		}
+		if version != expected {
+			t.Fatalf("expected version '%s', got '%s'", expected, version)
+		}
+	}
+}
+
+func TestJarVersionUnknown(t *testing.T) {
+	if _, err := JarVersion(createJar(t, map[string]string{"Main.class": ""})); err != ErrUnknownVersion {
+		t.Fatalf("expected ErrUnknownVersion, got %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package mcsmanager
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// logPollInterval is how often a log file is checked for new lines.
+const logPollInterval = 250 * time.Millisecond
+
+// LogSize returns the current size of a log file, so it can be used as
+// the offset to start watching the log from. A missing file has a size of 0.
+func LogSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return info.Size(), nil
+}
+
+// LogReader reads the lines that are added to a log file over time.
+//
+// If the file shrinks, e.g. because the server rotated its log, it is
+// read again from the beginning.
+type LogReader struct {
+	Path    string
+	offset  int64
+	partial string
+}
+
+// NewLogReader creates a reader for a log file that starts reading
+// at the given offset.
+func NewLogReader(path string, offset int64) *LogReader {
+	return &LogReader{Path: path, offset: offset}
+}
+
+// Lines returns all of the complete lines that were written to the log
+// since the last call. A partial line at the end of the log is kept
+// until the rest of it is written.
+func (r *LogReader) Lines() ([]string, error) {
+	size, err := LogSize(r.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	// The log was rotated, start over
+	if size < r.offset {
+		r.offset = 0
+		r.partial = ""
+	}
+
+	if size == r.offset {
+		return []string{}, nil
+	}
+
+	raw, n, err := readFrom(r.Path, r.offset)
+	if err != nil {
+		return nil, err
+	}
+	r.offset += n
+
+	// Only return complete lines, keeping any partial line for next time
+	raw = r.partial + raw
+	end := strings.LastIndex(raw, "\n")
+	r.partial = raw[end+1:]
+
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(strings.NewReader(raw[:end+1]))
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	return lines, scanner.Err()
+}
+
+// WaitForLog watches a log file for a line containing the given text,
+// only looking at lines written after the given offset.
+//
+// If the file shrinks, e.g. because the server rotated its log, it is
+// read again from the beginning. An error is returned if no matching
+// line shows up before the timeout.
+func WaitForLog(path string, offset int64, text string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	reader := NewLogReader(path, offset)
+
+	for {
+		lines, err := reader.Lines()
+		if err != nil {
+			return err
+		}
+
+		for _, line := range lines {
+			if strings.Contains(line, text) {
+				return nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %v waiting for '%s' in log", timeout, text)
+		}
+
+		time.Sleep(logPollInterval)
+	}
+}
+
+// readFrom reads everything in a file after the given offset.
+func readFrom(path string, offset int64) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	if _, err = file.Seek(offset, io.SeekStart); err != nil {
+		return "", 0, err
+	}
+
+	raw, err := io.ReadAll(file)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return string(raw), int64(len(raw)), nil
+}
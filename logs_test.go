@@ -0,0 +1,93 @@
+package mcsmanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWaitForLog(t *testing.T) {
+	// Given
+	path := filepath.Join(t.TempDir(), "latest.log")
+	if err := os.WriteFile(path, []byte("[12:00:00 INFO]: Saved the game\n"), 0644); err != nil {
+		t.Fatalf("error creating log file: %s\n", err)
+	}
+
+	offset, err := LogSize(path)
+	if err != nil {
+		t.Fatalf("error getting log size: %s\n", err)
+	}
+
+	// When
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+		f.WriteString("[12:00:01 INFO]: Saving the game (this may take a moment!)\n")
+		f.WriteString("[12:00:02 INFO]: Saved the game\n")
+		f.Close()
+	}()
+
+	// Then
+	if err = WaitForLog(path, offset, "Saved the game", 5*time.Second); err != nil {
+		t.Fatalf("error waiting for log line: %s\n", err)
+	}
+}
+
+func TestWaitForLogIgnoresOldLines(t *testing.T) {
+	// Given
+	path := filepath.Join(t.TempDir(), "latest.log")
+	if err := os.WriteFile(path, []byte("[12:00:00 INFO]: Saved the game\n"), 0644); err != nil {
+		t.Fatalf("error creating log file: %s\n", err)
+	}
+
+	offset, err := LogSize(path)
+	if err != nil {
+		t.Fatalf("error getting log size: %s\n", err)
+	}
+
+	// Then
+	if err = WaitForLog(path, offset, "Saved the game", 500*time.Millisecond); err == nil {
+		t.Fatal("expected to time out waiting for a new log line")
+	}
+}
+
+func TestLogReader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "latest.log")
+	if err := os.WriteFile(path, []byte("[12:00:00 INFO]: Starting\n[12:00:01 INFO]: Do"), 0644); err != nil {
+		t.Fatalf("error creating log file: %s\n", err)
+	}
+
+	reader := NewLogReader(path, 0)
+	lines, err := reader.Lines()
+	if err != nil {
+		t.Fatalf("error reading log: %s\n", err)
+	}
+	if len(lines) != 1 || lines[0] != "[12:00:00 INFO]: Starting" {
+		t.Fatalf("wrong lines read: %q", lines)
+	}
+
+	// The rest of the partial line is written
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString("ne!\n")
+	f.Close()
+
+	if lines, err = reader.Lines(); err != nil {
+		t.Fatalf("error reading log: %s\n", err)
+	}
+	if len(lines) != 1 || lines[0] != "[12:00:01 INFO]: Done!" {
+		t.Fatalf("wrong lines read: %q", lines)
+	}
+
+	// The log is rotated
+	if err := os.WriteFile(path, []byte("[12:05:00 INFO]: New\n"), 0644); err != nil {
+		t.Fatalf("error rotating log file: %s\n", err)
+	}
+
+	if lines, err = reader.Lines(); err != nil {
+		t.Fatalf("error reading log: %s\n", err)
+	}
+	if len(lines) != 1 || lines[0] != "[12:05:00 INFO]: New" {
+		t.Fatalf("wrong lines read after rotation: %q", lines)
+	}
+}
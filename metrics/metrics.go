@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Metric types of the Prometheus text format.
+const (
+	TypeGauge   = "gauge"
+	TypeCounter = "counter"
+)
+
+// ContentType is the content type of the Prometheus text format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Metric is a named metric with a value for each set of labels.
+type Metric struct {
+	Name    string
+	Help    string
+	Type    string
+	samples []sample
+}
+
+// sample is a single value of a metric.
+type sample struct {
+	labels []string
+	value  float64
+}
+
+// Registry holds a set of metrics in the order that they were added.
+type Registry struct {
+	metrics []*Metric
+	names   map[string]*Metric
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]*Metric)}
+}
+
+// Gauge returns the gauge with the given name, adding it if it doesn't
+// exist yet.
+func (r *Registry) Gauge(name, help string) *Metric {
+	return r.metric(name, help, TypeGauge)
+}
+
+// Counter returns the counter with the given name, adding it if it doesn't
+// exist yet.
+func (r *Registry) Counter(name, help string) *Metric {
+	return r.metric(name, help, TypeCounter)
+}
+
+// metric returns a metric, adding it if needed.
+func (r *Registry) metric(name, help, metricType string) *Metric {
+	if m, ok := r.names[name]; ok {
+		return m
+	}
+
+	m := &Metric{Name: name, Help: help, Type: metricType}
+	r.metrics = append(r.metrics, m)
+	r.names[name] = m
+	return m
+}
+
+// Set adds a value to the metric. The labels are given as pairs of names
+// and values, e.g. `Set(1, "server", "survival")`.
+func (m *Metric) Set(value float64, labels ...string) {
+	if len(labels)%2 != 0 {
+		panic(fmt.Sprintf("metrics: odd number of label names and values for %s", m.Name))
+	}
+
+	m.samples = append(m.samples, sample{labels: labels, value: value})
+}
+
+// Write writes all of the metrics in the Prometheus text format. Metrics
+// without any values are left out.
+func (r *Registry) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, m := range r.metrics {
+		if len(m.samples) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(bw, "# HELP %s %s\n", m.Name, escapeHelp(m.Help))
+		fmt.Fprintf(bw, "# TYPE %s %s\n", m.Name, m.Type)
+		for _, s := range m.samples {
+			bw.WriteString(m.Name)
+			if len(s.labels) > 0 {
+				bw.WriteByte('{')
+				for i := 0; i < len(s.labels); i += 2 {
+					if i > 0 {
+						bw.WriteByte(',')
+					}
+					fmt.Fprintf(bw, "%s=\"%s\"", s.labels[i], escapeLabel(s.labels[i+1]))
+				}
+				bw.WriteByte('}')
+			}
+			fmt.Fprintf(bw, " %s\n", formatValue(s.value))
+		}
+	}
+
+	return bw.Flush()
+}
+
+// escapeHelp escapes the help text of a metric.
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+// escapeLabel escapes the value of a label.
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(s)
+}
+
+// formatValue formats a value, including the special values.
+func formatValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	r := NewRegistry()
+	r.Gauge("mc_up", "Whether the server is running").Set(1, "server", "survival")
+	r.Gauge("mc_up", "").Set(0, "server", `say "hi"`)
+	r.Counter("mc_cpu_seconds_total", "CPU time").Set(12.5)
+	r.Gauge("mc_empty", "Never set")
+	r.Gauge("mc_latency", "Latency").Set(math.NaN())
+
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		t.Fatalf("error writing metrics: %s\n", err)
+	}
+
+	expected := `# HELP mc_up Whether the server is running
+# TYPE mc_up gauge
+mc_up{server="survival"} 1
+mc_up{server="say \"hi\""} 0
+# HELP mc_cpu_seconds_total CPU time
+# TYPE mc_cpu_seconds_total counter
+mc_cpu_seconds_total 12.5
+# HELP mc_latency Latency
+# TYPE mc_latency gauge
+mc_latency NaN
+`
+	if buf.String() != expected {
+		t.Fatalf("wrong output:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+// fakeProc creates a process in a fake proc directory.
+func fakeProc(t *testing.T, dir string, pid, comm, stat string, children string) {
+	taskDir := filepath.Join(dir, pid, "task", pid)
+	if err := os.MkdirAll(taskDir, 0755); err != nil {
+		t.Fatalf("error creating fake proc: %s\n", err)
+	}
+
+	files := map[string]string{
+		filepath.Join(dir, pid, "comm"):    comm + "\n",
+		filepath.Join(dir, pid, "stat"):    stat,
+		filepath.Join(taskDir, "children"): children,
+	}
+	for path, contents := range files {
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatalf("error creating fake proc: %s\n", err)
+		}
+	}
+}
+
+func TestProcess(t *testing.T) {
+	dir := t.TempDir()
+	ProcDir = dir
+	defer func() { ProcDir = "/proc" }()
+
+	fakeProc(t, dir, "100", "sh", "100 (sh) S 1 100 100 0 -1 0 0 0 0 0 1 1 0 0 20 0 1 0 1 1000 10", "200 ")
+	fakeProc(t, dir, "200", "java", "200 (java (server)) S 100 100 100 0 -1 0 0 0 0 0 250 50 0 0 20 0 40 0 1 1000 1000", "")
+
+	pid, err := FindProcess(100, "java")
+	if err != nil {
+		t.Fatalf("error finding process: %s\n", err)
+	}
+	if pid != 200 {
+		t.Fatalf("expected PID 200, got %d", pid)
+	}
+
+	proc, err := ReadProcess(pid)
+	if err != nil {
+		t.Fatalf("error reading process: %s\n", err)
+	}
+	if proc.CPUSeconds != 3 {
+		t.Fatalf("expected 3 CPU seconds, got %v", proc.CPUSeconds)
+	}
+	if proc.ResidentMemory != 1000*int64(os.Getpagesize()) {
+		t.Fatalf("wrong resident memory: %d", proc.ResidentMemory)
+	}
+
+	if _, err = FindProcess(200, "python"); err == nil {
+		t.Fatalf("expected an error for a missing process")
+	}
+}
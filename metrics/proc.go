@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// clockTicks is the number of clock ticks per second that the CPU times
+// in /proc are measured in. It is 100 on every Linux platform we run on.
+const clockTicks = 100
+
+// ProcDir is where the proc filesystem is mounted.
+var ProcDir = "/proc"
+
+// Process is the resource usage of a process.
+type Process struct {
+	PID int
+
+	// ResidentMemory is the resident set size in bytes.
+	ResidentMemory int64
+
+	// CPUSeconds is the total user and system CPU time used.
+	CPUSeconds float64
+}
+
+// FindProcess looks for a process with the given command name, starting with
+// the process with the given PID and then looking through its children.
+// The shell that tmux starts may run the server as a child process.
+func FindProcess(pid int, name string) (int, error) {
+	queue := []int{pid}
+	for len(queue) > 0 {
+		pid, queue = queue[0], queue[1:]
+
+		comm, err := os.ReadFile(filepath.Join(ProcDir, strconv.Itoa(pid), "comm"))
+		if err != nil {
+			return 0, err
+		}
+		if strings.TrimSpace(string(comm)) == name {
+			return pid, nil
+		}
+
+		children, err := children(pid)
+		if err != nil {
+			return 0, err
+		}
+		queue = append(queue, children...)
+	}
+
+	return 0, fmt.Errorf("no %s process found", name)
+}
+
+// ReadProcess reads the resource usage of a process.
+func ReadProcess(pid int) (Process, error) {
+	raw, err := os.ReadFile(filepath.Join(ProcDir, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return Process{}, err
+	}
+
+	// The command name is in parentheses and may contain spaces, so
+	// only split the fields after it
+	stat := string(raw)
+	end := strings.LastIndex(stat, ")")
+	if end < 0 {
+		return Process{}, fmt.Errorf("malformed stat for process %d", pid)
+	}
+	fields := strings.Fields(stat[end+1:])
+
+	// Fields are numbered from the state, which is field 3 in proc(5)
+	field := func(n int) (int64, error) {
+		if n-3 >= len(fields) {
+			return 0, fmt.Errorf("malformed stat for process %d", pid)
+		}
+		return strconv.ParseInt(fields[n-3], 10, 64)
+	}
+
+	utime, err := field(14)
+	if err != nil {
+		return Process{}, err
+	}
+	stime, err := field(15)
+	if err != nil {
+		return Process{}, err
+	}
+	rss, err := field(24)
+	if err != nil {
+		return Process{}, err
+	}
+
+	return Process{
+		PID:            pid,
+		ResidentMemory: rss * int64(os.Getpagesize()),
+		CPUSeconds:     float64(utime+stime) / clockTicks,
+	}, nil
+}
+
+// children returns the PIDs of the child processes of a process.
+func children(pid int) ([]int, error) {
+	tasks, err := os.ReadDir(filepath.Join(ProcDir, strconv.Itoa(pid), "task"))
+	if err != nil {
+		return nil, err
+	}
+
+	pids := make([]int, 0)
+	for _, task := range tasks {
+		raw, err := os.ReadFile(filepath.Join(ProcDir, strconv.Itoa(pid), "task", task.Name(), "children"))
+		if err != nil {
+			// Not every kernel has the children file
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		for _, field := range strings.Fields(string(raw)) {
+			if child, err := strconv.Atoi(field); err == nil {
+				pids = append(pids, child)
+			}
+		}
+	}
+
+	return pids, nil
+}
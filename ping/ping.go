@@ -0,0 +1,242 @@
+package ping
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultPort is the port that the server listens on if
+	// server.properties doesn't set one.
+	DefaultPort = 25565
+
+	// protocolVersion is sent in the handshake. -1 asks the server to
+	// report its own version instead of checking ours.
+	protocolVersion = -1
+
+	// maxResponse is the largest status response that we accept.
+	maxResponse = 1 << 20
+)
+
+// colorCode matches the formatting codes in a MOTD.
+var colorCode = regexp.MustCompile("§[0-9a-fk-orx]")
+
+// Status is what a server reports in the server list.
+type Status struct {
+	Version struct {
+		Name     string `json:"name"`
+		Protocol int    `json:"protocol"`
+	} `json:"version"`
+	Players struct {
+		Max    int `json:"max"`
+		Online int `json:"online"`
+		Sample []struct {
+			Name string `json:"name"`
+			ID   string `json:"id"`
+		} `json:"sample"`
+	} `json:"players"`
+	Description Description `json:"description"`
+
+	// Latency is how long the server took to answer a ping.
+	Latency time.Duration `json:"-"`
+}
+
+// Description is the MOTD of a server. Servers send it either as a plain
+// string or as a chat component.
+type Description struct {
+	Text  string        `json:"text"`
+	Extra []Description `json:"extra"`
+}
+
+// UnmarshalJSON reads a description from a string or a chat component.
+func (d *Description) UnmarshalJSON(raw []byte) error {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		d.Text = text
+		return nil
+	}
+
+	type component Description
+	return json.Unmarshal(raw, (*component)(d))
+}
+
+// String returns the plain text of a description, without any formatting.
+func (d Description) String() string {
+	var sb strings.Builder
+	sb.WriteString(d.Text)
+	for _, extra := range d.Extra {
+		sb.WriteString(extra.String())
+	}
+
+	return colorCode.ReplaceAllString(sb.String(), "")
+}
+
+// Ping asks the server at an address for its status using the Server List
+// Ping protocol, the same way the client does for its server list.
+func Ping(address string, timeout time.Duration) (*Status, error) {
+	host, rawPort, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.ParseUint(rawPort, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("ping: bad port '%s'", rawPort)
+	}
+
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	// Handshake, asking for the status
+	var handshake bytes.Buffer
+	writeVarInt(&handshake, protocolVersion)
+	writeString(&handshake, host)
+	binary.Write(&handshake, binary.BigEndian, uint16(port))
+	writeVarInt(&handshake, 1)
+	if err = writePacket(conn, 0x00, handshake.Bytes()); err != nil {
+		return nil, err
+	}
+	if err = writePacket(conn, 0x00, nil); err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(conn)
+	id, body, err := readPacket(r)
+	if err != nil {
+		return nil, err
+	}
+	if id != 0x00 {
+		return nil, fmt.Errorf("ping: unexpected packet %#x", id)
+	}
+	raw, err := readString(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{}
+	if err = json.Unmarshal([]byte(raw), status); err != nil {
+		return nil, fmt.Errorf("ping: bad status: %s", err)
+	}
+
+	// Measure the latency with a ping
+	var payload bytes.Buffer
+	sent := time.Now()
+	binary.Write(&payload, binary.BigEndian, sent.UnixNano())
+	if err = writePacket(conn, 0x01, payload.Bytes()); err != nil {
+		return nil, err
+	}
+	if id, body, err = readPacket(r); err != nil {
+		return nil, err
+	}
+	if id != 0x01 || !bytes.Equal(body, payload.Bytes()) {
+		return nil, errors.New("ping: bad pong from server")
+	}
+	status.Latency = time.Since(sent)
+
+	return status, nil
+}
+
+// writePacket writes a packet with its length and ID.
+func writePacket(w io.Writer, id int32, body []byte) error {
+	var data bytes.Buffer
+	writeVarInt(&data, id)
+	data.Write(body)
+
+	var packet bytes.Buffer
+	writeVarInt(&packet, int32(data.Len()))
+	packet.Write(data.Bytes())
+
+	_, err := w.Write(packet.Bytes())
+	return err
+}
+
+// readPacket reads a packet, returning its ID and body.
+func readPacket(r *bufio.Reader) (int32, []byte, error) {
+	length, err := readVarInt(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if length < 1 || length > maxResponse {
+		return 0, nil, fmt.Errorf("ping: bad packet length %d", length)
+	}
+
+	data := make([]byte, length)
+	if _, err = io.ReadFull(r, data); err != nil {
+		return 0, nil, err
+	}
+
+	body := bytes.NewReader(data)
+	id, err := readVarInt(body)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return id, data[len(data)-body.Len():], nil
+}
+
+// writeVarInt writes a number in the variable length format of the protocol.
+func writeVarInt(w *bytes.Buffer, n int32) {
+	value := uint32(n)
+	for {
+		if value&^0x7f == 0 {
+			w.WriteByte(byte(value))
+			return
+		}
+		w.WriteByte(byte(value&0x7f | 0x80))
+		value >>= 7
+	}
+}
+
+// readVarInt reads a number in the variable length format of the protocol.
+func readVarInt(r io.ByteReader) (int32, error) {
+	var value uint32
+	for i := 0; i < 5; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		value |= uint32(b&0x7f) << (7 * i)
+		if b&0x80 == 0 {
+			return int32(value), nil
+		}
+	}
+
+	return 0, errors.New("ping: VarInt is too long")
+}
+
+// writeString writes a string with its length.
+func writeString(w *bytes.Buffer, s string) {
+	writeVarInt(w, int32(len(s)))
+	w.WriteString(s)
+}
+
+// readString reads a string with its length.
+func readString(r *bytes.Reader) (string, error) {
+	length, err := readVarInt(r)
+	if err != nil {
+		return "", err
+	}
+	if length < 0 || int(length) > r.Len() {
+		return "", fmt.Errorf("ping: bad string length %d", length)
+	}
+
+	raw := make([]byte, length)
+	if _, err = io.ReadFull(r, raw); err != nil {
+		return "", err
+	}
+
+	return string(raw), nil
+}
@@ -0,0 +1,101 @@
+package ping
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+const statusJSON = `{
+	"version": {"name": "Paper 1.20.4", "protocol": 765},
+	"players": {"max": 20, "online": 2, "sample": [{"name": "Alice", "id": "1"}, {"name": "Bob", "id": "2"}]},
+	"description": {"text": "§aA ", "extra": [{"text": "Minecraft"}, {"text": " Server"}]}
+}`
+
+// fakeServer answers a single status request and ping.
+func fakeServer(t *testing.T, status string) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error starting fake server: %s\n", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+
+		// Handshake and status request
+		for i := 0; i < 2; i++ {
+			if _, _, err := readPacket(r); err != nil {
+				return
+			}
+		}
+
+		var body bytes.Buffer
+		writeString(&body, status)
+		writePacket(conn, 0x00, body.Bytes())
+
+		id, payload, err := readPacket(r)
+		if err != nil || id != 0x01 {
+			return
+		}
+		writePacket(conn, 0x01, payload)
+	}()
+
+	return listener.Addr().String()
+}
+
+func TestPing(t *testing.T) {
+	address := fakeServer(t, statusJSON)
+
+	status, err := Ping(address, 5*time.Second)
+	if err != nil {
+		t.Fatalf("error pinging server: %s\n", err)
+	}
+
+	if status.Version.Name != "Paper 1.20.4" || status.Version.Protocol != 765 {
+		t.Fatalf("wrong version: %+v", status.Version)
+	}
+	if status.Players.Online != 2 || status.Players.Max != 20 || len(status.Players.Sample) != 2 {
+		t.Fatalf("wrong players: %+v", status.Players)
+	}
+	if motd := status.Description.String(); motd != "A Minecraft Server" {
+		t.Fatalf("wrong MOTD: '%s'", motd)
+	}
+	if status.Latency <= 0 {
+		t.Fatalf("latency wasn't measured")
+	}
+}
+
+func TestPingPlainDescription(t *testing.T) {
+	address := fakeServer(t, `{"version": {"name": "1.8.9", "protocol": 47}, "players": {"max": 10, "online": 0}, "description": "Hello"}`)
+
+	status, err := Ping(address, 5*time.Second)
+	if err != nil {
+		t.Fatalf("error pinging server: %s\n", err)
+	}
+	if motd := status.Description.String(); motd != "Hello" {
+		t.Fatalf("wrong MOTD: '%s'", motd)
+	}
+}
+
+func TestVarInt(t *testing.T) {
+	for _, n := range []int32{0, 1, 127, 128, 255, 25565, 2097151, 2147483647, -1} {
+		var buf bytes.Buffer
+		writeVarInt(&buf, n)
+
+		got, err := readVarInt(&buf)
+		if err != nil {
+			t.Fatalf("error reading %d: %s\n", n, err)
+		}
+		if got != n {
+			t.Fatalf("expected %d, got %d", n, got)
+		}
+	}
+}
@@ -2,9 +2,14 @@ package properties
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 )
 
+// FileName is the name of the server properties file.
+const FileName = "server.properties"
+
 // Map is a map of server properties.
 type Map map[string]interface{}
 
@@ -17,13 +22,16 @@ func Read(raw []byte) (Map, error) {
 
 	lines := strings.Split(string(raw), "\n")
 	for i, line := range lines {
+		line = strings.TrimRight(line, "\r")
+
 		// Skip lines that are comments or blank
 		if strings.HasPrefix(line, "#") || len(line) == 0 {
 			continue
 		}
 
-		// Split the line into a key/value pair
-		parts := strings.Split(line, "=")
+		// Split the line into a key/value pair. Values may contain
+		// an equals sign, e.g. in the MOTD.
+		parts := strings.SplitN(line, "=", 2)
 		if len(parts) != 2 {
 			return nil, fmt.Errorf("malformed line: cannot split key and value:\n\tLine %d: '%s'", i, line)
 		}
@@ -34,3 +42,22 @@ func Read(raw []byte) (Map, error) {
 
 	return ret, nil
 }
+
+// Load reads the server.properties file in a server directory.
+func Load(dir string) (Map, error) {
+	raw, err := os.ReadFile(filepath.Join(dir, FileName))
+	if err != nil {
+		return nil, err
+	}
+
+	return Read(raw)
+}
+
+// Get returns the value of a property, or the fallback if it isn't set.
+func (m Map) Get(key, fallback string) string {
+	if value, ok := m[key].(string); ok && value != "" {
+		return value
+	}
+
+	return fallback
+}
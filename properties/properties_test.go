@@ -74,3 +74,17 @@ func TestParsesFormat(t *testing.T) {
 		t.Errorf("wrong number of entries: expected %d, got %d\n", count, len(result))
 	}
 }
+
+func TestValueWithEquals(t *testing.T) {
+	result, err := Read([]byte("motd=a=b\r\nlevel-name=world\r\n"))
+	if err != nil {
+		t.Fatalf("encountered an error while reading: %s", err)
+	}
+
+	if result["motd"] != "a=b" {
+		t.Errorf("wrong value for motd: expected 'a=b', got '%s'", result["motd"])
+	}
+	if result["level-name"] != "world" {
+		t.Errorf("wrong value for level-name: expected 'world', got '%s'", result["level-name"])
+	}
+}
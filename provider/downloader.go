@@ -1,12 +1,17 @@
 package provider
 
 import (
+	"crypto/md5"
+	"crypto/sha1"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"hash"
 	"io"
 	"net/http"
 	"os"
+	"strings"
 
 	"github.com/cheggaaa/pb/v3"
 )
@@ -34,7 +39,7 @@ func DownloadFile(url string, filepath string) error {
 	// Create our progress bar to report the download progress
 	bar := pb.New64(resp.ContentLength)
 	bar.Set(pb.SIBytesPrefix, true)
-	bar.SetWriter(os.Stdout)
+	bar.SetWriter(os.Stderr)
 	bar.SetMaxWidth(80)
 	bar.Start()
 
@@ -50,23 +55,74 @@ func DownloadFile(url string, filepath string) error {
 	return nil
 }
 
+// replaceFile downloads a url next to a file, checks the download with the
+// given function if there is one, and then replaces the file with it. The
+// file is left untouched if anything goes wrong.
+func replaceFile(url, path string, verify func(string) error) error {
+	download := path + ".download"
+	defer os.Remove(download)
+
+	if err := DownloadFile(url, download); err != nil {
+		return err
+	}
+	if verify != nil {
+		if err := verify(download); err != nil {
+			return err
+		}
+	}
+
+	return os.Rename(download, path)
+}
+
 // Verify makes sure that the downloaded file's hash matches what the expected hash is.
 // The hasing function used is `sha256`.
 func Verify(path string, expected string) error {
+	return verifyHash(path, expected, sha256.New())
+}
+
+// VerifySHA1 makes sure that the downloaded file's `sha1` hash matches the
+// expected hash.
+func VerifySHA1(path string, expected string) error {
+	return verifyHash(path, expected, sha1.New())
+}
+
+// VerifyMD5 makes sure that the downloaded file's `md5` hash matches the
+// expected hash.
+func VerifyMD5(path string, expected string) error {
+	return verifyHash(path, expected, md5.New())
+}
+
+// verifyHash hashes a file with the given hashing function, and compares
+// it to the expected hash.
+func verifyHash(path string, expected string, h hash.Hash) error {
 	file, err := os.Open(path)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	sha256 := sha256.New()
-	if _, err := io.Copy(sha256, file); err != nil {
+	if _, err := io.Copy(h, file); err != nil {
 		return err
 	}
 
-	if hash := hex.EncodeToString(sha256.Sum(nil)); hash != expected {
-		return fmt.Errorf("hash mismatch: got %s, but expected %s", hash, expected)
+	if sum := hex.EncodeToString(h.Sum(nil)); !strings.EqualFold(sum, expected) {
+		return fmt.Errorf("hash mismatch: got %s, but expected %s", sum, expected)
 	}
 
 	return nil
 }
+
+// getJSON fetches a URL and decodes the JSON response into v.
+func getJSON(url string, v interface{}) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("status code not ok for '%s': %d", url, resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
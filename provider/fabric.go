@@ -0,0 +1,249 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	fabricGameEndpoint      = "%s/versions/game"
+	fabricLoaderEndpoint    = "%s/versions/loader"
+	fabricInstallerEndpoint = "%s/versions/installer"
+	fabricLauncherEndpoint  = "%s/versions/loader/%s/%s/%s/server/jar"
+)
+
+// Base URLs of the Fabric and Quilt meta APIs.
+var (
+	fabricMeta = "https://meta.fabricmc.net/v2"
+	quiltMeta  = "https://meta.quiltmc.org/v3"
+)
+
+// Names of the files that the installed loader versions are saved in, next
+// to the server jar.
+const (
+	FabricFile = ".fabric_versions.json"
+	QuiltFile  = ".quilt_versions.json"
+)
+
+// quiltLauncher is the name of the jar that the Quilt installer creates.
+const quiltLauncher = "quilt-server-launch.jar"
+
+// Fabric is an update provider that downloads the Fabric server launcher
+// for a Minecraft version, using the latest stable loader and installer.
+// The launcher downloads the vanilla server when it first starts.
+type Fabric struct {
+	Version string
+}
+
+// Quilt is an update provider that installs the Quilt server launcher for
+// a Minecraft version, using the latest stable loader and installer. Quilt
+// doesn't serve launcher jars, so the installer is run in the server
+// directory instead.
+type Quilt struct {
+	Version string
+}
+
+// LoaderVersions are the versions that make up a Fabric or Quilt server.
+type LoaderVersions struct {
+	Game      string `json:"game"`
+	Loader    string `json:"loader"`
+	Installer string `json:"installer"`
+}
+
+// metaVersion is a version in a list from the Fabric or Quilt meta API.
+type metaVersion struct {
+	Version string `json:"version"`
+	Stable  *bool  `json:"stable"`
+	URL     string `json:"url"`
+}
+
+// isStable checks if a version is a stable release. Quilt doesn't mark its
+// loader versions as stable, so pre-release versions are found by their
+// version string instead.
+func (v metaVersion) isStable() bool {
+	if v.Stable != nil {
+		return *v.Stable
+	}
+
+	return !strings.Contains(v.Version, "-")
+}
+
+// Download gets the Fabric server launcher from the Fabric meta API.
+func (f Fabric) Download(path string) error {
+	versions, _, err := resolveLoader(fabricMeta, f.Version)
+	if err != nil {
+		return err
+	}
+
+	statePath := filepath.Join(filepath.Dir(path), FabricFile)
+	if err = checkInstalled(statePath, versions); err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf(fabricLauncherEndpoint, fabricMeta, versions.Game, versions.Loader, versions.Installer)
+	if err = replaceFile(url, path, nil); err != nil {
+		return err
+	}
+
+	if err = versions.Save(statePath); err != nil {
+		return fmt.Errorf("unable to save version file: %s", err)
+	}
+
+	return nil
+}
+
+// Download runs the Quilt installer to install the Quilt server launcher.
+//
+// The installer puts the vanilla server at `server.jar`, which could be
+// the name of the server jar, so it is moved into `.quilt/server` first.
+func (q Quilt) Download(path string) error {
+	versions, installer, err := resolveLoader(quiltMeta, q.Version)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	statePath := filepath.Join(dir, QuiltFile)
+	if err = checkInstalled(statePath, versions); err != nil {
+		return err
+	}
+
+	// Download the installer next to the server, and always clean it up
+	jar, err := os.CreateTemp(dir, ".quilt-installer-*.jar")
+	if err != nil {
+		return err
+	}
+	jar.Close()
+	defer os.Remove(jar.Name())
+
+	if err = DownloadFile(installer.URL, jar.Name()); err != nil {
+		return err
+	}
+
+	args := []string{"install", "server", versions.Game, versions.Loader, "--install-dir=" + dir, "--download-server"}
+	if err = runInstaller(dir, jar.Name(), args...); err != nil {
+		return fmt.Errorf("the Quilt installer failed: %s", err)
+	}
+
+	// Move the vanilla server out of the way, and point the launcher to it
+	vanilla := filepath.Join(".quilt", "server", versions.Game+"-server.jar")
+	if err = os.MkdirAll(filepath.Join(dir, filepath.Dir(vanilla)), 0755); err != nil {
+		return err
+	}
+	if err = os.Rename(filepath.Join(dir, "server.jar"), filepath.Join(dir, vanilla)); err != nil {
+		return fmt.Errorf("unable to move the vanilla server: %s", err)
+	}
+	properties := fmt.Sprintf("serverJar=%s\n", filepath.ToSlash(vanilla))
+	if err = os.WriteFile(filepath.Join(dir, "quilt-server-launcher.properties"), []byte(properties), 0644); err != nil {
+		return err
+	}
+
+	if err = os.Rename(filepath.Join(dir, quiltLauncher), path); err != nil {
+		return fmt.Errorf("unable to move the Quilt launcher: %s", err)
+	}
+
+	if err = versions.Save(statePath); err != nil {
+		return fmt.Errorf("unable to save version file: %s", err)
+	}
+
+	return nil
+}
+
+// resolveLoader checks that a Minecraft version is supported by a meta API,
+// and finds the latest stable loader and installer for it. The installer
+// is returned as well, for its download URL.
+func resolveLoader(meta, game string) (*LoaderVersions, *metaVersion, error) {
+	var games []metaVersion
+	if err := getJSON(fmt.Sprintf(fabricGameEndpoint, meta), &games); err != nil {
+		return nil, nil, fmt.Errorf("unable to get game versions: %s", err)
+	}
+
+	found := false
+	for _, version := range games {
+		if version.Version == game {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, nil, fmt.Errorf("server version not found: %s", game)
+	}
+
+	loader, err := latestStable(fmt.Sprintf(fabricLoaderEndpoint, meta))
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to get loader versions: %s", err)
+	}
+
+	installer, err := latestStable(fmt.Sprintf(fabricInstallerEndpoint, meta))
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to get installer versions: %s", err)
+	}
+
+	versions := &LoaderVersions{Game: game, Loader: loader.Version, Installer: installer.Version}
+	return versions, installer, nil
+}
+
+// latestStable gets a list of versions from a meta API, and returns the
+// first stable one. The lists are sorted from newest to oldest.
+func latestStable(url string) (*metaVersion, error) {
+	var versions []metaVersion
+	if err := getJSON(url, &versions); err != nil {
+		return nil, err
+	}
+
+	for _, version := range versions {
+		if version.isStable() {
+			return &version, nil
+		}
+	}
+
+	return nil, errors.New("there are no stable versions")
+}
+
+// checkInstalled returns ErrAlreadyUpToDate if the versions saved in a
+// file are the same as the given versions.
+func checkInstalled(path string, versions *LoaderVersions) error {
+	saved, err := LoadLoaderVersions(path)
+	if err != nil {
+		return fmt.Errorf("unable to read old version: %s", err)
+	}
+	if *saved == *versions {
+		return ErrAlreadyUpToDate
+	}
+
+	return nil
+}
+
+// LoadLoaderVersions reads the installed loader versions from a file. If
+// the file does not exist, an empty struct and no error is returned.
+func LoadLoaderVersions(path string) (*LoaderVersions, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &LoaderVersions{}, nil
+		}
+		return nil, err
+	}
+
+	var versions LoaderVersions
+	if err = json.Unmarshal(raw, &versions); err != nil {
+		return nil, err
+	}
+
+	return &versions, nil
+}
+
+// Save writes the installed loader versions to a file.
+func (v LoaderVersions) Save(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return json.NewEncoder(file).Encode(v)
+}
@@ -0,0 +1,139 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeMeta serves the version lists of the Fabric or Quilt meta API. The
+// newest loader and installer are pre-releases, and Quilt style lists
+// don't say which versions are stable.
+func fakeMeta(t *testing.T, meta *string, quilt bool) *httptest.Server {
+	stable := func(value bool) string {
+		if quilt {
+			return ""
+		}
+		return fmt.Sprintf(`, "stable": %t`, value)
+	}
+
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/versions/game", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[{"version": "24w05a"%s}, {"version": "1.20.4"%s}]`, stable(false), stable(true))
+	})
+	mux.HandleFunc("/versions/loader", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[{"version": "0.16.0-beta.1"%s}, {"version": "0.15.6"%s}]`, stable(false), stable(true))
+	})
+	mux.HandleFunc("/versions/installer", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[{"version": "1.1.0-beta.1", "url": "%[1]s/bad.jar"%[2]s}, {"version": "1.0.0", "url": "%[1]s/installer.jar"%[3]s}]`,
+			server.URL, stable(false), stable(true))
+	})
+	mux.HandleFunc("/versions/loader/1.20.4/0.15.6/1.0.0/server/jar", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("fabric launcher"))
+	})
+	mux.HandleFunc("/installer.jar", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("quilt installer"))
+	})
+
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	old := *meta
+	*meta = server.URL
+	t.Cleanup(func() { *meta = old })
+
+	return server
+}
+
+func TestFabricDownload(t *testing.T) {
+	fakeMeta(t, &fabricMeta, false)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.jar")
+	if err := (Fabric{Version: "1.20.4"}).Download(path); err != nil {
+		t.Fatalf("error downloading: %s\n", err)
+	}
+
+	if raw, err := os.ReadFile(path); err != nil || string(raw) != "fabric launcher" {
+		t.Fatalf("expected the launcher to be downloaded, got '%s' (%v)", raw, err)
+	}
+
+	saved, err := LoadLoaderVersions(filepath.Join(dir, FabricFile))
+	if err != nil {
+		t.Fatalf("error loading versions: %s\n", err)
+	}
+	expected := LoaderVersions{Game: "1.20.4", Loader: "0.15.6", Installer: "1.0.0"}
+	if *saved != expected {
+		t.Fatalf("expected %+v, got %+v", expected, *saved)
+	}
+
+	if err = (Fabric{Version: "1.20.4"}).Download(path); err != ErrAlreadyUpToDate {
+		t.Fatalf("expected ErrAlreadyUpToDate, got %v", err)
+	}
+	if err = (Fabric{Version: "1.7.10"}).Download(path); err == nil {
+		t.Fatalf("expected an error for an unknown version")
+	}
+
+	// A failed download leaves the installed launcher alone
+	if err = (Fabric{Version: "24w05a"}).Download(path); err == nil {
+		t.Fatalf("expected an error for a missing launcher")
+	}
+	readJar(t, path, "fabric launcher")
+}
+
+func TestQuiltDownload(t *testing.T) {
+	fakeMeta(t, &quiltMeta, true)
+
+	// Pretend to be the installer, which puts the launcher and the vanilla
+	// server in the install directory
+	var installed []string
+	old := runInstaller
+	runInstaller = func(dir, jar string, args ...string) error {
+		if raw, err := os.ReadFile(jar); err != nil || string(raw) != "quilt installer" {
+			t.Fatalf("expected the installer to be downloaded, got '%s' (%v)", raw, err)
+		}
+		installed = args
+
+		os.WriteFile(filepath.Join(dir, quiltLauncher), []byte("quilt launcher"), 0644)
+		return os.WriteFile(filepath.Join(dir, "server.jar"), []byte("vanilla"), 0644)
+	}
+	t.Cleanup(func() { runInstaller = old })
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.jar")
+	if err := (Quilt{Version: "1.20.4"}).Download(path); err != nil {
+		t.Fatalf("error downloading: %s\n", err)
+	}
+
+	if len(installed) < 4 || installed[2] != "1.20.4" || installed[3] != "0.15.6" {
+		t.Fatalf("unexpected installer args: %v", installed)
+	}
+
+	files := map[string]string{
+		"server.jar":                       "quilt launcher",
+		".quilt/server/1.20.4-server.jar":  "vanilla",
+		"quilt-server-launcher.properties": "serverJar=.quilt/server/1.20.4-server.jar\n",
+	}
+	for name, expected := range files {
+		if raw, err := os.ReadFile(filepath.Join(dir, name)); err != nil || string(raw) != expected {
+			t.Fatalf("expected '%s' in %s, got '%s' (%v)", expected, name, raw, err)
+		}
+	}
+
+	// Only the server files should be left behind
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("error reading dir: %s\n", err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("expected 4 files, got %d", len(entries))
+	}
+
+	if err = (Quilt{Version: "1.20.4"}).Download(path); err != ErrAlreadyUpToDate {
+		t.Fatalf("expected ErrAlreadyUpToDate, got %v", err)
+	}
+}
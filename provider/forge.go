@@ -0,0 +1,282 @@
+package provider
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Maven repositories and version lists of Forge and NeoForge.
+var (
+	forgeMaven      = "https://maven.minecraftforge.net"
+	forgePromotions = "https://files.minecraftforge.net/net/minecraftforge/forge/promotions_slim.json"
+	neoforgeMaven   = "https://maven.neoforged.net/releases"
+)
+
+// Paths of the Forge and NeoForge artifacts in their Maven repositories.
+const (
+	forgeArtifact    = "net/minecraftforge/forge"
+	neoforgeArtifact = "net/neoforged/neoforge"
+)
+
+// Names of the files that the installed Forge or NeoForge version is
+// saved in, next to the server jar.
+const (
+	ForgeFile    = ".forge_version.json"
+	NeoForgeFile = ".neoforge_version.json"
+)
+
+// Launcher is a provider that can change how the server is launched.
+type Launcher interface {
+	Provider
+
+	// ArgsFile returns the Java argument file to launch the server with,
+	// relative to the server directory, after a download. It is empty
+	// if the server jar should be launched directly.
+	ArgsFile() string
+}
+
+// Forge is an update provider that runs the Forge installer in the server
+// directory. The version is either a Minecraft version, to get the
+// recommended (or else latest) Forge build for it, or a full Forge
+// version such as "1.20.4-49.0.30".
+type Forge struct {
+	Version  string
+	argsFile string
+}
+
+// NeoForge is an update provider that runs the NeoForge installer in the
+// server directory. The version is either a Minecraft version, to get the
+// latest NeoForge build for it, or a NeoForge version such as "20.4.190".
+type NeoForge struct {
+	Version  string
+	argsFile string
+}
+
+// ForgeInstall is the Forge or NeoForge version that is installed.
+type ForgeInstall struct {
+	Version  string `json:"version"`
+	ArgsFile string `json:"args_file,omitempty"`
+}
+
+// forgePromos is the list of recommended and latest Forge builds.
+type forgePromos struct {
+	Promos map[string]string `json:"promos"`
+}
+
+// mavenMetadata is the list of versions of an artifact in a Maven repository.
+type mavenMetadata struct {
+	Versions []string `xml:"versioning>versions>version"`
+}
+
+// Download installs Forge into the server directory.
+func (f *Forge) Download(path string) (err error) {
+	full := f.Version
+	if !strings.Contains(full, "-") {
+		var promos forgePromos
+		if err = getJSON(forgePromotions, &promos); err != nil {
+			return fmt.Errorf("unable to get Forge versions: %s", err)
+		}
+
+		build, ok := promos.Promos[f.Version+"-recommended"]
+		if !ok {
+			build, ok = promos.Promos[f.Version+"-latest"]
+		}
+		if !ok {
+			return fmt.Errorf("server version not found: %s", f.Version)
+		}
+		full = f.Version + "-" + build
+	}
+
+	f.argsFile, err = installForge(path, ForgeFile, forgeMaven, forgeArtifact, full)
+	return
+}
+
+// ArgsFile returns the Java argument file of the installed Forge version.
+func (f *Forge) ArgsFile() string {
+	return f.argsFile
+}
+
+// Download installs NeoForge into the server directory.
+func (n *NeoForge) Download(path string) (err error) {
+	version := n.Version
+	if strings.HasPrefix(version, "1.") {
+		if version, err = latestNeoForge(version); err != nil {
+			return err
+		}
+	}
+
+	n.argsFile, err = installForge(path, NeoForgeFile, neoforgeMaven, neoforgeArtifact, version)
+	return
+}
+
+// ArgsFile returns the Java argument file of the installed NeoForge version.
+func (n *NeoForge) ArgsFile() string {
+	return n.argsFile
+}
+
+// latestNeoForge finds the latest NeoForge version for a Minecraft version.
+// NeoForge versions start with the minor and patch version of Minecraft,
+// e.g. "20.4.190" for 1.20.4. Stable versions are preferred over betas.
+func latestNeoForge(game string) (string, error) {
+	parts := strings.Split(strings.TrimPrefix(game, "1."), ".")
+	if len(parts) == 1 {
+		parts = append(parts, "0")
+	}
+	prefix := strings.Join(parts, ".") + "."
+
+	resp, err := http.Get(neoforgeMaven + "/" + neoforgeArtifact + "/maven-metadata.xml")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("failed to get NeoForge versions: %d", resp.StatusCode)
+	}
+
+	var metadata mavenMetadata
+	if err = xml.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+		return "", err
+	}
+
+	// Versions are listed from oldest to newest
+	latest := ""
+	for _, version := range metadata.Versions {
+		if !strings.HasPrefix(version, prefix) {
+			continue
+		}
+		if !strings.Contains(version, "-") || latest == "" || strings.Contains(latest, "-") {
+			latest = version
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("server version not found: %s", game)
+	}
+
+	return latest, nil
+}
+
+// installForge downloads a Forge or NeoForge installer and runs it in the
+// server directory. Returns the Java argument file that the installer
+// created, or an empty string for old versions that are still launched
+// from a jar, in which case the jar is moved to the server jar path.
+func installForge(jarPath, stateName, maven, artifact, version string) (string, error) {
+	dir := filepath.Dir(jarPath)
+	statePath := filepath.Join(dir, stateName)
+
+	saved, err := LoadForge(statePath)
+	if err != nil {
+		return "", fmt.Errorf("unable to read old version: %s", err)
+	}
+	if saved.Version == version {
+		return saved.ArgsFile, ErrAlreadyUpToDate
+	}
+
+	// Download the installer next to the server, and always clean it up
+	name := path.Base(artifact)
+	url := fmt.Sprintf("%s/%s/%s/%s-%s-installer.jar", maven, artifact, version, name, version)
+	jar, err := os.CreateTemp(dir, "."+name+"-installer-*.jar")
+	if err != nil {
+		return "", err
+	}
+	jar.Close()
+	defer os.Remove(jar.Name())
+	defer os.Remove(jar.Name() + ".log")
+
+	if err = DownloadFile(url, jar.Name()); err != nil {
+		return "", err
+	}
+	sum, err := getText(url + ".sha1")
+	if err != nil {
+		return "", fmt.Errorf("unable to get the installer hash: %s", err)
+	}
+	if err = VerifySHA1(jar.Name(), sum); err != nil {
+		return "", err
+	}
+
+	if err = runInstaller(dir, jar.Name(), "--installServer"); err != nil {
+		return "", fmt.Errorf("the %s installer failed: %s", name, err)
+	}
+
+	install := ForgeInstall{Version: version}
+
+	// Since Minecraft 1.17, the server is launched with an argument file
+	argsFile := filepath.Join("libraries", filepath.FromSlash(artifact), version, "unix_args.txt")
+	if _, err = os.Stat(filepath.Join(dir, argsFile)); err == nil {
+		install.ArgsFile = argsFile
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	} else if err = os.Rename(filepath.Join(dir, fmt.Sprintf("%s-%s.jar", name, version)), jarPath); err != nil {
+		return "", fmt.Errorf("unable to find the server that the installer created: %s", err)
+	}
+
+	if err = install.Save(statePath); err != nil {
+		return "", fmt.Errorf("unable to save version file: %s", err)
+	}
+
+	return install.ArgsFile, nil
+}
+
+// getText fetches a URL and returns the first word of the response, such
+// as the hash in a Maven `.sha1` file.
+func getText(url string) (string, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("status code not ok for '%s': %d", url, resp.StatusCode)
+	}
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	fields := strings.Fields(string(raw))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("empty response from '%s'", url)
+	}
+
+	return fields[0], nil
+}
+
+// LoadForge reads the installed Forge or NeoForge version from a file. If
+// the file does not exist, an empty struct and no error is returned.
+func LoadForge(path string) (*ForgeInstall, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &ForgeInstall{}, nil
+		}
+		return nil, err
+	}
+
+	var install ForgeInstall
+	if err = json.Unmarshal(raw, &install); err != nil {
+		return nil, err
+	}
+
+	return &install, nil
+}
+
+// Save writes the installed Forge or NeoForge version to a file.
+func (i ForgeInstall) Save(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return json.NewEncoder(file).Encode(i)
+}
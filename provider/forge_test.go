@@ -0,0 +1,203 @@
+package provider
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeForge serves Forge and NeoForge installers and version lists, the
+// same way as their Maven repositories.
+func fakeForge(t *testing.T) {
+	installer := []byte("installer")
+	sum := sha1.Sum(installer)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/promotions_slim.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"promos": {"1.20.4-latest": "49.0.30", "1.16.5-recommended": "36.2.34", "1.16.5-latest": "36.2.39"}}`))
+	})
+	mux.HandleFunc("/releases/net/neoforged/neoforge/maven-metadata.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<metadata><versioning><versions>
+			<version>20.4.80-beta</version>
+			<version>20.4.190</version>
+			<version>20.4.191-beta</version>
+			<version>20.6.1-beta</version>
+		</versions></versioning></metadata>`))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "-installer.jar"):
+			w.Write(installer)
+		case strings.HasSuffix(r.URL.Path, "-installer.jar.sha1"):
+			w.Write([]byte(hex.EncodeToString(sum[:])))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	oldForge, oldPromotions, oldNeoForge := forgeMaven, forgePromotions, neoforgeMaven
+	forgeMaven = server.URL + "/forge"
+	forgePromotions = server.URL + "/promotions_slim.json"
+	neoforgeMaven = server.URL + "/releases"
+	t.Cleanup(func() {
+		forgeMaven, forgePromotions, neoforgeMaven = oldForge, oldPromotions, oldNeoForge
+	})
+}
+
+// fakeInstaller pretends to be the Forge installer. Modern versions create
+// an argument file, and old versions create a server jar.
+func fakeInstaller(t *testing.T) {
+	old := runInstaller
+	runInstaller = func(dir, jar string, args ...string) error {
+		if len(args) != 1 || args[0] != "--installServer" {
+			t.Fatalf("unexpected installer args: %v", args)
+		}
+
+		name := strings.TrimSuffix(filepath.Base(jar), filepath.Ext(jar))
+		os.WriteFile(jar+".log", []byte("log"), 0644)
+		switch {
+		case strings.HasPrefix(name, ".forge-"):
+			if _, err := os.Stat(filepath.Join(dir, "legacy")); err == nil {
+				return os.WriteFile(filepath.Join(dir, "forge-1.16.5-36.2.34.jar"), []byte("forge"), 0644)
+			}
+			return writeArgs(dir, "net/minecraftforge/forge/1.20.4-49.0.30")
+		default:
+			return writeArgs(dir, "net/neoforged/neoforge/20.4.190")
+		}
+	}
+	t.Cleanup(func() { runInstaller = old })
+}
+
+// writeArgs creates an argument file for a version in the libraries directory.
+func writeArgs(dir, version string) error {
+	path := filepath.Join(dir, "libraries", filepath.FromSlash(version))
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(path, "unix_args.txt"), []byte("-cp libraries"), 0644)
+}
+
+func TestForgeDownload(t *testing.T) {
+	fakeForge(t)
+	fakeInstaller(t)
+
+	dir := t.TempDir()
+	prov := MatchProvider([]string{"forge", "1.20.4"}).(Launcher)
+	if err := prov.Download(filepath.Join(dir, "server.jar")); err != nil {
+		t.Fatalf("error installing: %s\n", err)
+	}
+
+	expected := filepath.Join("libraries", "net", "minecraftforge", "forge", "1.20.4-49.0.30", "unix_args.txt")
+	if prov.ArgsFile() != expected {
+		t.Fatalf("expected args file '%s', got '%s'", expected, prov.ArgsFile())
+	}
+
+	// The installer and its log are cleaned up
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("error reading dir: %s\n", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected only the libraries and version file, got %d files", len(entries))
+	}
+
+	// Installing again keeps the args file
+	prov = MatchProvider([]string{"forge", "1.20.4-49.0.30"}).(Launcher)
+	if err = prov.Download(filepath.Join(dir, "server.jar")); err != ErrAlreadyUpToDate {
+		t.Fatalf("expected ErrAlreadyUpToDate, got %v", err)
+	}
+	if prov.ArgsFile() != expected {
+		t.Fatalf("expected args file '%s', got '%s'", expected, prov.ArgsFile())
+	}
+}
+
+func TestForgeDownloadLegacy(t *testing.T) {
+	fakeForge(t)
+	fakeInstaller(t)
+
+	dir := t.TempDir()
+	os.Mkdir(filepath.Join(dir, "legacy"), 0755)
+
+	prov := &Forge{Version: "1.16.5"}
+	path := filepath.Join(dir, "server.jar")
+	if err := prov.Download(path); err != nil {
+		t.Fatalf("error installing: %s\n", err)
+	}
+
+	if prov.ArgsFile() != "" {
+		t.Fatalf("expected no args file, got '%s'", prov.ArgsFile())
+	}
+	if raw, err := os.ReadFile(path); err != nil || string(raw) != "forge" {
+		t.Fatalf("expected the Forge jar to be moved to the server jar, got '%s' (%v)", raw, err)
+	}
+}
+
+func TestNeoForgeDownload(t *testing.T) {
+	fakeForge(t)
+	fakeInstaller(t)
+
+	dir := t.TempDir()
+	prov := &NeoForge{Version: "1.20.4"}
+	if err := prov.Download(filepath.Join(dir, "server.jar")); err != nil {
+		t.Fatalf("error installing: %s\n", err)
+	}
+
+	saved, err := LoadForge(filepath.Join(dir, NeoForgeFile))
+	if err != nil {
+		t.Fatalf("error loading version: %s\n", err)
+	}
+	if saved.Version != "20.4.190" || saved.ArgsFile != prov.ArgsFile() {
+		t.Fatalf("unexpected saved version: %+v", saved)
+	}
+}
+
+func TestLatestNeoForge(t *testing.T) {
+	fakeForge(t)
+
+	cases := map[string]string{
+		"1.20.4": "20.4.190",
+		"1.20.6": "20.6.1-beta",
+	}
+	for game, expected := range cases {
+		version, err := latestNeoForge(game)
+		if err != nil {
+			t.Fatalf("error finding version for %s: %s\n", game, err)
+		}
+		if version != expected {
+			t.Fatalf("expected '%s' for %s, got '%s'", expected, game, version)
+		}
+	}
+
+	if version, err := latestNeoForge("1.21"); err == nil {
+		t.Fatalf("expected an error for a version without builds, got '%s'", version)
+	}
+}
+
+func TestForgeDownloadHashMismatch(t *testing.T) {
+	fakeForge(t)
+	fakeInstaller(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".sha1") {
+			fmt.Fprint(w, "0000000000000000000000000000000000000000")
+			return
+		}
+		w.Write([]byte("installer"))
+	}))
+	t.Cleanup(server.Close)
+	forgeMaven = server.URL
+
+	if err := (&Forge{Version: "1.20.4-49.0.30"}).Download(filepath.Join(t.TempDir(), "server.jar")); err == nil {
+		t.Fatalf("expected an error for an installer with the wrong hash")
+	}
+}
@@ -0,0 +1,17 @@
+package provider
+
+import (
+	"os"
+	"os/exec"
+)
+
+// runInstaller runs an installer jar with Java in a directory. Its output
+// goes to stderr, like the progress bars.
+var runInstaller = func(dir, jar string, args ...string) error {
+	command := exec.Command("java", append([]string{"-jar", jar}, args...)...)
+	command.Dir = dir
+	command.Stdout = os.Stderr
+	command.Stderr = os.Stderr
+
+	return command.Run()
+}
@@ -6,82 +6,215 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
 
 	"github.com/stretchr/stew/slice"
 )
 
 const (
-	paperProjectEndpoint  = "https://papermc.io/api/v2/projects/paper"
-	paperVersionsEndpoint = "https://papermc.io/api/v2/projects/paper/versions/%s"
-	paperBuildEndpoint    = "https://papermc.io/api/v2/projects/paper/versions/%s/builds/%d"
-	paperDownloadEndpoint = "https://papermc.io/api/v2/projects/paper/versions/%s/builds/%d/downloads/%s"
+	paperProjectEndpoint  = "%s/projects/%s"
+	paperVersionsEndpoint = "%s/projects/%s/versions/%s"
+	paperBuildEndpoint    = "%s/projects/%s/versions/%s/builds/%d"
+	paperDownloadEndpoint = "%s/projects/%s/versions/%s/builds/%d/downloads/%s"
 )
 
+// paperAPI is the base URL of the PaperMC API.
+var paperAPI = "https://papermc.io/api/v2"
+
 // ErrAErrAlreadyUpToDate is an error returned when the server is already
 // at the latest build for the given version.
-var ErrAlreadyUpToDate = errors.New("paper version is already at the latest build")
+var ErrAlreadyUpToDate = errors.New("server jar is already at the latest build")
+
+// ErrNoRollback is returned when there is no earlier server jar to go
+// back to.
+var ErrNoRollback = errors.New("there is no earlier build to roll back to")
 
-// Paper is an update provider that downloads a new Paper server version.
+// Paper is an update provider that downloads a new server version of a
+// PaperMC project, such as Paper, Velocity, Waterfall, or Folia. The
+// latest build is downloaded, unless a build is given.
 type Paper struct {
+	Project string
 	Version string
+	Build   int
 }
 
-// Download gets the latest build of Paper from their website
-// for the given Minecraft version.
-func (p Paper) Download(filepath string) error {
+// Download gets a build of the project from the PaperMC API for the
+// given version.
+//
+// The installed build is saved next to the server jar, in a file named
+// after the project, e.g. `.paper_build.json`. The jar that was installed
+// before is kept, so the update can be rolled back with RollbackPaper.
+func (p Paper) Download(path string) error {
 	// See if we actially have a valid version
 	valid, err := p.validateVersion()
 	if err != nil {
 		return err
 	}
 	if !valid {
-		return fmt.Errorf("server version not found: %s", p.Version)
+		return fmt.Errorf("%s version not found: %s", p.Project, p.Version)
 	}
 
-	// Get the latest build number
-	b, err := getLatestBuild(p.Version)
+	// Get the build to install
+	b, err := p.getBuild()
 	if err != nil {
 		return err
 	}
 
 	// Check if we have the version we're currently running saved
-	saved, err := Load(".paper_build.json")
+	dir := filepath.Dir(path)
+	statePath := filepath.Join(dir, BuildFile(p.Project))
+	saved, err := Load(statePath)
 	if err != nil {
 		return fmt.Errorf("unable to read old version: %s", err.Error())
 	}
 
-	// Check if the current version and build matches the latest
-	if saved.Version == b.Version {
-		if saved.Build == b.Build {
-			return ErrAlreadyUpToDate
-		}
+	// Check if the current version and build matches the latest. Files
+	// saved before projects were recorded are always for Paper.
+	if saved.Project == "" {
+		saved.Project = PaperProject
 	}
+	if saved.Project == b.Project && saved.Version == b.Version && saved.Build == b.Build {
+		return ErrAlreadyUpToDate
+	}
+
+	// Download the actual jar file next to the old one, so the old one
+	// is untouched if anything goes wrong
+	download := path + ".download"
+	defer os.Remove(download)
 
-	// Download the actual jar file
-	url := fmt.Sprintf(paperDownloadEndpoint, p.Version, b.Build, b.Download.Application.Name)
-	if err = DownloadFile(url, filepath); err != nil {
+	url := fmt.Sprintf(paperDownloadEndpoint, paperAPI, p.Project, p.Version, b.Build, b.Download.Application.Name)
+	if err = DownloadFile(url, download); err != nil {
+		return err
+	}
+
+	// Verify the downloaded file
+	if err = Verify(download, b.Download.Application.Hash); err != nil {
+		return err
+	}
+
+	// Keep the old jar to be able to roll back to it
+	if saved.Build != 0 {
+		if err = keepPrevious(path, p.Project, saved); err != nil {
+			return fmt.Errorf("unable to keep the old server jar: %s", err)
+		}
+	}
+	if err = os.Rename(download, path); err != nil {
 		return err
 	}
 
 	// Save the new version and build to disk
-	if err = b.Save(".paper_build.json"); err != nil {
+	if err = b.Save(statePath); err != nil {
 		return fmt.Errorf("unable to save version file: %s", err.Error())
 	}
 
-	// Verify the downloaded file
-	return Verify(filepath, b.Download.Application.Hash)
+	return nil
 }
 
-// validateVersion queries the Paper API to see if we have a valid version string.
+// RollbackPaper goes back to the server jar of a PaperMC project that was
+// installed before the last update, along with its saved build. The jar
+// that is replaced is kept in turn, so rolling back again undoes it.
+// Returns the build that is now installed.
+func RollbackPaper(path, project string) (*PaperBuild, error) {
+	dir := filepath.Dir(path)
+	statePath := filepath.Join(dir, BuildFile(project))
+	previousPath := filepath.Join(dir, PreviousBuildFile(project))
+
+	previous, err := Load(previousPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read previous version: %s", err)
+	}
+	if previous.Build == 0 {
+		return nil, ErrNoRollback
+	}
+
+	previousJar := filepath.Join(dir, previous.JarName(project))
+	if _, err = os.Stat(previousJar); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNoRollback
+		}
+		return nil, err
+	}
+
+	current, err := Load(statePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read old version: %s", err)
+	}
+
+	// Move the previous jar out of the way first, since keeping the
+	// current jar replaces the previous one
+	restore := path + ".rollback"
+	if err = os.Rename(previousJar, restore); err != nil {
+		return nil, err
+	}
+
+	// Keep the current jar, so the rollback can be undone
+	if current.Build != 0 {
+		err = keepPrevious(path, project, current)
+	} else {
+		err = os.Remove(previousPath)
+	}
+	if err != nil {
+		os.Rename(restore, previousJar)
+		return nil, fmt.Errorf("unable to keep the current server jar: %s", err)
+	}
+
+	if err = os.Rename(restore, path); err != nil {
+		return nil, err
+	}
+	if err = previous.Save(statePath); err != nil {
+		return nil, fmt.Errorf("unable to save version file: %s", err)
+	}
+
+	return previous, nil
+}
+
+// keepPrevious renames the installed jar after its version and build, and
+// saves its build as the previous build. Only one previous jar is kept.
+func keepPrevious(path, project string, installed *PaperBuild) error {
+	dir := filepath.Dir(path)
+	previousPath := filepath.Join(dir, PreviousBuildFile(project))
+
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	// Remove the jar that was kept before
+	if previous, err := Load(previousPath); err == nil && previous.Build != 0 {
+		os.Remove(filepath.Join(dir, previous.JarName(project)))
+	}
+
+	if err := os.Rename(path, filepath.Join(dir, installed.JarName(project))); err != nil {
+		return err
+	}
+
+	return installed.Save(previousPath)
+}
+
+// BuildFile returns the name of the file that the installed build of a
+// PaperMC project is saved in.
+func BuildFile(project string) string {
+	return fmt.Sprintf(".%s_build.json", project)
+}
+
+// PreviousBuildFile returns the name of the file that the build of a
+// PaperMC project that was installed before the last update is saved in.
+func PreviousBuildFile(project string) string {
+	return fmt.Sprintf(".%s_build.previous.json", project)
+}
+
+// validateVersion queries the PaperMC API to see if we have a valid version string.
 func (p Paper) validateVersion() (bool, error) {
-	resp, err := http.Get(paperProjectEndpoint)
+	resp, err := http.Get(fmt.Sprintf(paperProjectEndpoint, paperAPI, p.Project))
 	if err != nil {
 		return false, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return false, fmt.Errorf("failed to get version list: %d", resp.StatusCode)
+		return false, fmt.Errorf("failed to get version list for '%s': %d", p.Project, resp.StatusCode)
 	}
 
 	dec := json.NewDecoder(resp.Body)
@@ -105,8 +238,10 @@ type PaperBuilds struct {
 	Builds []int `json:"builds"`
 }
 
-// PaperBuild holds the API response data for a particular Paper build.
+// PaperBuild holds the API response data for a particular build of a
+// PaperMC project.
 type PaperBuild struct {
+	Project  string        `json:"project_id"`
 	Build    int           `json:"build"`
 	Download PaperDownload `json:"downloads"`
 	Version  string        `json:"version"`
@@ -149,6 +284,16 @@ func Load(path string) (*PaperBuild, error) {
 	return &b, nil
 }
 
+// JarName returns the name that the jar of a build is kept under after it
+// is replaced, e.g. `paper-1.20.4-435.jar`.
+func (p PaperBuild) JarName(project string) string {
+	if p.Project != "" {
+		project = p.Project
+	}
+
+	return fmt.Sprintf("%s-%s-%d.jar", project, p.Version, p.Build)
+}
+
 // Save write a Paper build to a file on disk.
 func (p PaperBuild) Save(path string) error {
 	file, err := os.Create(path)
@@ -160,11 +305,11 @@ func (p PaperBuild) Save(path string) error {
 	return json.NewEncoder(file).Encode(p)
 }
 
-// getLatestBuild queries the Paper API to get the latest build for the
-// version of Minecraft we were given.
-func getLatestBuild(version string) (*PaperBuild, error) {
+// getBuild queries the PaperMC API to get the build that we were given for
+// the version, or the latest build if we weren't given one.
+func (p Paper) getBuild() (*PaperBuild, error) {
 	// Get the list of builds for the given version
-	url := fmt.Sprintf(paperVersionsEndpoint, version)
+	url := fmt.Sprintf(paperVersionsEndpoint, paperAPI, p.Project, p.Version)
 	resp, err := http.Get(url)
 	if err != nil {
 		return nil, err
@@ -172,7 +317,7 @@ func getLatestBuild(version string) (*PaperBuild, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("failed to get builds for version '%s': %d", version, resp.StatusCode)
+		return nil, fmt.Errorf("failed to get builds for version '%s': %d", p.Version, resp.StatusCode)
 	}
 
 	dec := json.NewDecoder(resp.Body)
@@ -181,18 +326,27 @@ func getLatestBuild(version string) (*PaperBuild, error) {
 	if err != nil {
 		return nil, err
 	}
+	if len(builds.Builds) == 0 {
+		return nil, fmt.Errorf("there are no builds for version '%s'", p.Version)
+	}
 
 	build := builds.Builds[len(builds.Builds)-1]
+	if p.Build != 0 {
+		if !slice.Contains(builds.Builds, p.Build) {
+			return nil, fmt.Errorf("build %d not found for version '%s'", p.Build, p.Version)
+		}
+		build = p.Build
+	}
 
-	// Get the latest build info for this version
-	url = fmt.Sprintf(paperBuildEndpoint, version, build)
+	// Get the build info for this version
+	url = fmt.Sprintf(paperBuildEndpoint, paperAPI, p.Project, p.Version, build)
 	buildResp, err := http.Get(url)
 	if err != nil {
 		return nil, err
 	}
 	defer buildResp.Body.Close()
 
-	if resp.StatusCode != 200 {
+	if buildResp.StatusCode != 200 {
 		return nil, fmt.Errorf("failed to get build info: %d", buildResp.StatusCode)
 	}
 
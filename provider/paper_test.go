@@ -0,0 +1,170 @@
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakePaperAPI serves a project with one version and two builds, the same
+// way as the PaperMC API. The jar of each build is the given jar followed
+// by the build number.
+func fakePaperAPI(t *testing.T, project, version string, jar []byte) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/projects/"+project, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(PaperVersions{Versions: []string{version}})
+	})
+	mux.HandleFunc("/projects/"+project+"/versions/"+version, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(PaperBuilds{Builds: []int{1, 2}})
+	})
+
+	for _, build := range []int{1, 2} {
+		build := build
+		contents := append(append([]byte{}, jar...), fmt.Sprint(build)...)
+		sum := sha256.Sum256(contents)
+		name := fmt.Sprintf("%s-%s-%d.jar", project, version, build)
+		endpoint := fmt.Sprintf("/projects/%s/versions/%s/builds/%d", project, version, build)
+
+		mux.HandleFunc(endpoint, func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(PaperBuild{
+				Project:  project,
+				Build:    build,
+				Version:  version,
+				Download: PaperDownload{Application: PaperApplication{Name: name, Hash: hex.EncodeToString(sum[:])}},
+			})
+		})
+		mux.HandleFunc(endpoint+"/downloads/"+name, func(w http.ResponseWriter, r *http.Request) {
+			w.Write(contents)
+		})
+	}
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	old := paperAPI
+	paperAPI = server.URL
+	t.Cleanup(func() { paperAPI = old })
+}
+
+// readJar reads a file, failing the test if it doesn't have the contents.
+func readJar(t *testing.T, path, expected string) {
+	raw, err := os.ReadFile(path)
+	if err != nil || string(raw) != expected {
+		t.Fatalf("expected '%s' in %s, got '%s' (%v)", expected, filepath.Base(path), raw, err)
+	}
+}
+
+func TestPaperDownload(t *testing.T) {
+	jar := []byte("velocity jar")
+	fakePaperAPI(t, VelocityProject, "3.3.0-SNAPSHOT", jar)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.jar")
+	prov := MatchProvider([]string{"velocity", "3.3.0-SNAPSHOT"})
+	if err := prov.Download(path); err != nil {
+		t.Fatalf("error downloading: %s\n", err)
+	}
+
+	readJar(t, path, string(jar)+"2")
+
+	saved, err := Load(filepath.Join(dir, ".velocity_build.json"))
+	if err != nil {
+		t.Fatalf("error loading build: %s\n", err)
+	}
+	if saved.Project != VelocityProject || saved.Version != "3.3.0-SNAPSHOT" || saved.Build != 2 {
+		t.Fatalf("unexpected saved build: %+v", saved)
+	}
+
+	if err = prov.Download(path); err != ErrAlreadyUpToDate {
+		t.Fatalf("expected ErrAlreadyUpToDate, got %v", err)
+	}
+}
+
+func TestPaperDownloadUnknownVersion(t *testing.T) {
+	fakePaperAPI(t, PaperProject, "1.20.4", []byte("paper jar"))
+
+	prov := Paper{Project: PaperProject, Version: "1.8.8"}
+	if err := prov.Download(filepath.Join(t.TempDir(), "server.jar")); err == nil {
+		t.Fatalf("expected an error for an unknown version")
+	}
+}
+
+func TestMatchProvider(t *testing.T) {
+	cases := map[string]Provider{
+		"paper":  Paper{Project: PaperProject, Version: "1.20.4"},
+		"Folia":  Paper{Project: FoliaProject, Version: "1.20.4"},
+		"forked": nil,
+	}
+
+	for name, expected := range cases {
+		if prov := MatchProvider([]string{name, "1.20.4"}); prov != expected {
+			t.Fatalf("expected %+v for '%s', got %+v", expected, name, prov)
+		}
+	}
+}
+
+func TestPaperDownloadBuild(t *testing.T) {
+	fakePaperAPI(t, PaperProject, "1.20.4", []byte("paper jar "))
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.jar")
+	if err := (Paper{Project: PaperProject, Version: "1.20.4", Build: 1}).Download(path); err != nil {
+		t.Fatalf("error downloading: %s\n", err)
+	}
+	readJar(t, path, "paper jar 1")
+
+	if err := (Paper{Project: PaperProject, Version: "1.20.4", Build: 3}).Download(path); err == nil {
+		t.Fatalf("expected an error for a missing build")
+	}
+}
+
+func TestRollbackPaper(t *testing.T) {
+	fakePaperAPI(t, PaperProject, "1.20.4", []byte("paper jar "))
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.jar")
+	if _, err := RollbackPaper(path, PaperProject); err != ErrNoRollback {
+		t.Fatalf("expected ErrNoRollback, got %v", err)
+	}
+
+	// Install build 1, and then update to build 2
+	if err := (Paper{Project: PaperProject, Version: "1.20.4", Build: 1}).Download(path); err != nil {
+		t.Fatalf("error downloading: %s\n", err)
+	}
+	if err := (Paper{Project: PaperProject, Version: "1.20.4"}).Download(path); err != nil {
+		t.Fatalf("error downloading: %s\n", err)
+	}
+	readJar(t, path, "paper jar 2")
+	readJar(t, filepath.Join(dir, "paper-1.20.4-1.jar"), "paper jar 1")
+
+	// Rolling back swaps the jars and the saved builds
+	build, err := RollbackPaper(path, PaperProject)
+	if err != nil {
+		t.Fatalf("error rolling back: %s\n", err)
+	}
+	if build.Build != 1 {
+		t.Fatalf("expected build 1, got %d", build.Build)
+	}
+	readJar(t, path, "paper jar 1")
+	readJar(t, filepath.Join(dir, "paper-1.20.4-2.jar"), "paper jar 2")
+	if _, err = os.Stat(filepath.Join(dir, "paper-1.20.4-1.jar")); err == nil {
+		t.Fatalf("expected the old jar to be moved back")
+	}
+
+	saved, err := Load(filepath.Join(dir, BuildFile(PaperProject)))
+	if err != nil || saved.Build != 1 {
+		t.Fatalf("expected build 1 to be saved, got %+v (%v)", saved, err)
+	}
+
+	// Rolling back again undoes the rollback
+	if build, err = RollbackPaper(path, PaperProject); err != nil || build.Build != 2 {
+		t.Fatalf("expected to roll back to build 2, got %+v (%v)", build, err)
+	}
+	readJar(t, path, "paper jar 2")
+}
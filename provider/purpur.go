@@ -0,0 +1,217 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/stretchr/stew/slice"
+)
+
+const (
+	purpurProjectEndpoint  = "%s/purpur"
+	purpurVersionEndpoint  = "%s/purpur/%s"
+	purpurBuildEndpoint    = "%s/purpur/%s/%s"
+	purpurDownloadEndpoint = "%s/purpur/%s/%s/download"
+
+	pufferfishBuildEndpoint    = "%s/job/Pufferfish-%s/lastSuccessfulBuild/api/json"
+	pufferfishDownloadEndpoint = "%s/job/Pufferfish-%s/%d/artifact/%s"
+)
+
+// Base URLs of the Purpur API and the Pufferfish build server.
+var (
+	purpurAPI     = "https://api.purpurmc.org/v2"
+	pufferfishAPI = "https://ci.pufferfish.host"
+)
+
+// Names of the forks that are downloaded from their own APIs.
+const (
+	PurpurProject     = "purpur"
+	PufferfishProject = "pufferfish"
+)
+
+// Purpur is an update provider that downloads the latest successful build
+// of Purpur for a Minecraft version.
+type Purpur struct {
+	Version string
+}
+
+// Pufferfish is an update provider that downloads the latest successful
+// build of Pufferfish for a Minecraft version. Pufferfish only builds the
+// newest patch of each Minecraft version, e.g. 1.20.4 for 1.20.
+type Pufferfish struct {
+	Version string
+}
+
+// ForkBuild is the build of a Paper fork that is installed.
+type ForkBuild struct {
+	Project string `json:"project"`
+	Version string `json:"version"`
+	Build   string `json:"build"`
+}
+
+// PurpurVersions is the list of Minecraft versions that Purpur supports.
+type PurpurVersions struct {
+	Versions []string `json:"versions"`
+}
+
+// PurpurBuilds is the list of Purpur builds for a Minecraft version.
+type PurpurBuilds struct {
+	Builds struct {
+		Latest string   `json:"latest"`
+		All    []string `json:"all"`
+	} `json:"builds"`
+}
+
+// PurpurBuild holds the API response data for a particular Purpur build.
+type PurpurBuild struct {
+	Build   string `json:"build"`
+	MD5     string `json:"md5"`
+	Result  string `json:"result"`
+	Version string `json:"version"`
+}
+
+// jenkinsBuild is a build of a Jenkins job, with the files it created.
+type jenkinsBuild struct {
+	Number    int `json:"number"`
+	Artifacts []struct {
+		FileName     string `json:"fileName"`
+		RelativePath string `json:"relativePath"`
+	} `json:"artifacts"`
+	Fingerprints []struct {
+		FileName string `json:"fileName"`
+		Hash     string `json:"hash"`
+	} `json:"fingerprint"`
+}
+
+// Download gets the latest successful build of Purpur from the Purpur API,
+// and checks it against the MD5 hash of the build.
+func (p Purpur) Download(path string) error {
+	var versions PurpurVersions
+	if err := getJSON(fmt.Sprintf(purpurProjectEndpoint, purpurAPI), &versions); err != nil {
+		return fmt.Errorf("unable to get Purpur versions: %s", err)
+	}
+	if !slice.Contains(versions.Versions, p.Version) {
+		return fmt.Errorf("purpur version not found: %s", p.Version)
+	}
+
+	var builds PurpurBuilds
+	if err := getJSON(fmt.Sprintf(purpurVersionEndpoint, purpurAPI, p.Version), &builds); err != nil {
+		return fmt.Errorf("unable to get builds for version '%s': %s", p.Version, err)
+	}
+	if len(builds.Builds.All) == 0 {
+		return fmt.Errorf("there are no builds for version '%s'", p.Version)
+	}
+
+	// Builds are listed from oldest to newest, and some of them failed
+	var b PurpurBuild
+	for i := len(builds.Builds.All) - 1; i >= 0 && b.Result != "SUCCESS"; i-- {
+		b = PurpurBuild{}
+		if err := getJSON(fmt.Sprintf(purpurBuildEndpoint, purpurAPI, p.Version, builds.Builds.All[i]), &b); err != nil {
+			return fmt.Errorf("unable to get build info: %s", err)
+		}
+	}
+	if b.Result != "SUCCESS" {
+		return fmt.Errorf("there are no successful builds for version '%s'", p.Version)
+	}
+
+	build := ForkBuild{Project: PurpurProject, Version: p.Version, Build: b.Build}
+	url := fmt.Sprintf(purpurDownloadEndpoint, purpurAPI, p.Version, b.Build)
+	return installFork(path, build, url, b.MD5)
+}
+
+// Download gets the latest successful build of Pufferfish from its build
+// server. The MD5 hash of the jar is checked if the build server has one.
+func (p Pufferfish) Download(path string) error {
+	// Jobs are named after the minor version, e.g. Pufferfish-1.20
+	parts := strings.Split(p.Version, ".")
+	if len(parts) < 2 {
+		return fmt.Errorf("pufferfish version not found: %s", p.Version)
+	}
+	job := strings.Join(parts[:2], ".")
+
+	var b jenkinsBuild
+	if err := getJSON(fmt.Sprintf(pufferfishBuildEndpoint, pufferfishAPI, job), &b); err != nil {
+		return fmt.Errorf("pufferfish version not found: %s (%s)", p.Version, err)
+	}
+
+	// Find the server jar for the exact version
+	for _, artifact := range b.Artifacts {
+		if !strings.HasSuffix(artifact.FileName, ".jar") || !strings.Contains(artifact.FileName, "-"+p.Version+"-") {
+			continue
+		}
+
+		hash := ""
+		for _, fingerprint := range b.Fingerprints {
+			if fingerprint.FileName == artifact.FileName {
+				hash = fingerprint.Hash
+			}
+		}
+
+		build := ForkBuild{Project: PufferfishProject, Version: p.Version, Build: fmt.Sprint(b.Number)}
+		url := fmt.Sprintf(pufferfishDownloadEndpoint, pufferfishAPI, job, b.Number, artifact.RelativePath)
+		return installFork(path, build, url, hash)
+	}
+
+	return fmt.Errorf("pufferfish version not found: %s (the latest build is for a different version)", p.Version)
+}
+
+// installFork downloads a build of a fork if it isn't installed already,
+// checks its MD5 hash if there is one, and saves the build.
+func installFork(path string, build ForkBuild, url, hash string) error {
+	statePath := filepath.Join(filepath.Dir(path), BuildFile(build.Project))
+	saved, err := LoadFork(statePath)
+	if err != nil {
+		return fmt.Errorf("unable to read old version: %s", err)
+	}
+	if *saved == build {
+		return ErrAlreadyUpToDate
+	}
+
+	var verify func(string) error
+	if hash != "" {
+		verify = func(download string) error { return VerifyMD5(download, hash) }
+	}
+	if err = replaceFile(url, path, verify); err != nil {
+		return err
+	}
+
+	if err = build.Save(statePath); err != nil {
+		return fmt.Errorf("unable to save version file: %s", err)
+	}
+
+	return nil
+}
+
+// LoadFork reads the installed build of a fork from a file. If the file
+// does not exist, an empty struct and no error is returned.
+func LoadFork(path string) (*ForkBuild, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &ForkBuild{}, nil
+		}
+		return nil, err
+	}
+
+	var build ForkBuild
+	if err = json.Unmarshal(raw, &build); err != nil {
+		return nil, err
+	}
+
+	return &build, nil
+}
+
+// Save writes the installed build of a fork to a file.
+func (b ForkBuild) Save(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return json.NewEncoder(file).Encode(b)
+}
@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeForks serves the Purpur API and the Pufferfish build server, with
+// one successful build of each. The latest Purpur build failed.
+func fakeForks(t *testing.T, jar []byte) {
+	sum := md5.Sum(jar)
+	hash := hex.EncodeToString(sum[:])
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/purpur", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"project": "purpur", "versions": ["1.20.2", "1.20.4"]}`)
+	})
+	mux.HandleFunc("/purpur/1.20.4", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"builds": {"latest": "2096", "all": ["2094", "2095", "2096"]}, "project": "purpur", "version": "1.20.4"}`)
+	})
+	mux.HandleFunc("/purpur/1.20.4/2096", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"build": "2096", "md5": "", "result": "FAILURE", "version": "1.20.4"}`)
+	})
+	mux.HandleFunc("/purpur/1.20.2", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"builds": {"latest": "2090", "all": ["2090"]}, "project": "purpur", "version": "1.20.2"}`)
+	})
+	mux.HandleFunc("/purpur/1.20.4/2095", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"build": "2095", "md5": "%s", "result": "SUCCESS", "version": "1.20.4"}`, hash)
+	})
+	mux.HandleFunc("/purpur/1.20.2/2090", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"build": "2090", "md5": "", "result": "FAILURE", "version": "1.20.2"}`)
+	})
+	mux.HandleFunc("/purpur/1.20.4/2095/download", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(jar)
+	})
+	mux.HandleFunc("/job/Pufferfish-1.20/lastSuccessfulBuild/api/json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{
+			"number": 42,
+			"artifacts": [{"fileName": "pufferfish-paperclip-1.20.4-R0.1-SNAPSHOT-reobf.jar", "relativePath": "build/libs/pufferfish.jar"}],
+			"fingerprint": [{"fileName": "pufferfish-paperclip-1.20.4-R0.1-SNAPSHOT-reobf.jar", "hash": "%s"}]
+		}`, hash)
+	})
+	mux.HandleFunc("/job/Pufferfish-1.20/42/artifact/build/libs/pufferfish.jar", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(jar)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	oldPurpur, oldPufferfish := purpurAPI, pufferfishAPI
+	purpurAPI, pufferfishAPI = server.URL, server.URL
+	t.Cleanup(func() { purpurAPI, pufferfishAPI = oldPurpur, oldPufferfish })
+}
+
+func TestForkDownload(t *testing.T) {
+	jar := []byte("fork jar")
+	fakeForks(t, jar)
+
+	cases := map[string]ForkBuild{
+		"purpur":     {Project: PurpurProject, Version: "1.20.4", Build: "2095"},
+		"pufferfish": {Project: PufferfishProject, Version: "1.20.4", Build: "42"},
+	}
+	for name, expected := range cases {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "server.jar")
+		prov := MatchProvider([]string{name, "1.20.4"})
+		if err := prov.Download(path); err != nil {
+			t.Fatalf("error downloading %s: %s\n", name, err)
+		}
+
+		if raw, err := os.ReadFile(path); err != nil || string(raw) != string(jar) {
+			t.Fatalf("expected the %s jar to be downloaded, got '%s' (%v)", name, raw, err)
+		}
+
+		saved, err := LoadFork(filepath.Join(dir, BuildFile(name)))
+		if err != nil {
+			t.Fatalf("error loading build: %s\n", err)
+		}
+		if *saved != expected {
+			t.Fatalf("expected %+v, got %+v", expected, *saved)
+		}
+
+		if err = prov.Download(path); err != ErrAlreadyUpToDate {
+			t.Fatalf("expected ErrAlreadyUpToDate for %s, got %v", name, err)
+		}
+	}
+}
+
+func TestForkDownloadErrors(t *testing.T) {
+	fakeForks(t, []byte("fork jar"))
+
+	cases := map[string]Provider{
+		"unknown Purpur version":     Purpur{Version: "1.8.8"},
+		"failed Purpur build":        Purpur{Version: "1.20.2"},
+		"unknown Pufferfish job":     Pufferfish{Version: "1.19.4"},
+		"older Pufferfish patch":     Pufferfish{Version: "1.20.1"},
+		"Pufferfish without a minor": Pufferfish{Version: "1"},
+	}
+	for name, prov := range cases {
+		if err := prov.Download(filepath.Join(t.TempDir(), "server.jar")); err == nil {
+			t.Fatalf("expected an error for %s", name)
+		}
+	}
+}
+
+func TestForkDownloadHashMismatch(t *testing.T) {
+	fakeForks(t, []byte("fork jar"))
+
+	// Serve a different jar than the one that was hashed
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("tampered jar"))
+	}))
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.jar")
+	if err := os.WriteFile(path, []byte("working jar"), 0644); err != nil {
+		t.Fatalf("error creating server jar: %s\n", err)
+	}
+
+	build := ForkBuild{Project: PurpurProject, Version: "1.20.4", Build: "2095"}
+	sum := md5.Sum([]byte("fork jar"))
+	if err := installFork(path, build, server.URL, hex.EncodeToString(sum[:])); err == nil {
+		t.Fatalf("expected an error for a jar with the wrong hash")
+	}
+	if _, err := os.Stat(filepath.Join(dir, BuildFile(PurpurProject))); err == nil {
+		t.Fatalf("expected the build not to be saved")
+	}
+	readJar(t, path, "working jar")
+}
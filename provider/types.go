@@ -2,6 +2,8 @@ package provider
 
 import (
 	"strings"
+
+	"github.com/stretchr/stew/slice"
 )
 
 const (
@@ -10,8 +12,52 @@ const (
 
 	// PaperProvider is an update provider that downloads the server jar from PaperMC.
 	PaperProvider = "PAPER"
+
+	// VanillaProvider is an update provider that downloads the official server jar from Mojang.
+	VanillaProvider = "VANILLA"
+
+	// FabricProvider is an update provider that downloads the Fabric server launcher.
+	FabricProvider = "FABRIC"
+
+	// QuiltProvider is an update provider that installs the Quilt server launcher.
+	QuiltProvider = "QUILT"
+
+	// ForgeProvider is an update provider that runs the Forge installer.
+	ForgeProvider = "FORGE"
+
+	// NeoForgeProvider is an update provider that runs the NeoForge installer.
+	NeoForgeProvider = "NEOFORGE"
+
+	// PurpurProvider is an update provider that downloads the server jar from Purpur.
+	PurpurProvider = "PURPUR"
+
+	// PufferfishProvider is an update provider that downloads the server jar from Pufferfish.
+	PufferfishProvider = "PUFFERFISH"
 )
 
+// Projects on the PaperMC API that can be downloaded with the Paper provider.
+const (
+	PaperProject     = "paper"
+	VelocityProject  = "velocity"
+	WaterfallProject = "waterfall"
+	FoliaProject     = "folia"
+)
+
+// PaperProjects are the projects that can be downloaded with the Paper provider.
+var PaperProjects = []string{PaperProject, VelocityProject, WaterfallProject, FoliaProject}
+
+// StateFiles returns the names of the files that the providers save the
+// installed version in, in the server directory. A provider writes its file
+// every time it updates the server.
+func StateFiles() []string {
+	files := []string{VanillaFile, FabricFile, QuiltFile, ForgeFile, NeoForgeFile}
+	for _, project := range append(append([]string{}, PaperProjects...), PurpurProject, PufferfishProject) {
+		files = append(files, BuildFile(project))
+	}
+
+	return files
+}
+
 // Provider is an interface for a Minecraft server jar provider, such as PaperMC.
 type Provider interface {
 	Download(string) error
@@ -29,9 +75,23 @@ func MatchProvider(args []string) (prov Provider) {
 	} else if len(args) == 2 {
 		providerType := strings.ToUpper(args[0])
 
-		switch providerType {
-		case PaperProvider:
-			prov = Paper{Version: args[1]}
+		switch {
+		case providerType == VanillaProvider:
+			prov = Vanilla{Version: args[1]}
+		case providerType == FabricProvider:
+			prov = Fabric{Version: args[1]}
+		case providerType == QuiltProvider:
+			prov = Quilt{Version: args[1]}
+		case providerType == ForgeProvider:
+			prov = &Forge{Version: args[1]}
+		case providerType == NeoForgeProvider:
+			prov = &NeoForge{Version: args[1]}
+		case providerType == PurpurProvider:
+			prov = Purpur{Version: args[1]}
+		case providerType == PufferfishProvider:
+			prov = Pufferfish{Version: args[1]}
+		case slice.Contains(PaperProjects, strings.ToLower(providerType)):
+			prov = Paper{Project: strings.ToLower(providerType), Version: args[1]}
 		default:
 			prov = nil
 		}
@@ -0,0 +1,156 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// VanillaFile is the name of the file that the installed vanilla version is
+// saved in, next to the server jar.
+const VanillaFile = ".vanilla_version.json"
+
+// vanillaManifest is the URL of Mojang's list of every Minecraft version.
+var vanillaManifest = "https://piston-meta.mojang.com/mc/game/version_manifest_v2.json"
+
+// Vanilla is an update provider that downloads the official server jar
+// from Mojang. The version can be a Minecraft version, or "release" or
+// "snapshot" for the latest one of each.
+type Vanilla struct {
+	Version string
+}
+
+// VanillaManifest is the list of every Minecraft version.
+type VanillaManifest struct {
+	Latest struct {
+		Release  string `json:"release"`
+		Snapshot string `json:"snapshot"`
+	} `json:"latest"`
+	Versions []VanillaVersion `json:"versions"`
+}
+
+// VanillaVersion is a Minecraft version in the version manifest.
+type VanillaVersion struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	URL  string `json:"url"`
+	SHA1 string `json:"sha1"`
+}
+
+// VanillaPackage is the part of a version's JSON file that lists its
+// downloads.
+type VanillaPackage struct {
+	ID        string `json:"id"`
+	Downloads struct {
+		Server *VanillaDownload `json:"server"`
+	} `json:"downloads"`
+}
+
+// VanillaDownload is a file that can be downloaded for a version.
+type VanillaDownload struct {
+	SHA1 string `json:"sha1"`
+	Size int64  `json:"size"`
+	URL  string `json:"url"`
+}
+
+// VanillaInstall is the vanilla version that is installed.
+type VanillaInstall struct {
+	Version string `json:"version"`
+	SHA1    string `json:"sha1"`
+}
+
+// Download gets the server jar for the version from Mojang, and checks its
+// hash against the one in the version's JSON file.
+func (v Vanilla) Download(path string) error {
+	version, err := v.resolve()
+	if err != nil {
+		return err
+	}
+
+	var pkg VanillaPackage
+	if err = getJSON(version.URL, &pkg); err != nil {
+		return fmt.Errorf("unable to get info for version '%s': %s", version.ID, err)
+	}
+	server := pkg.Downloads.Server
+	if server == nil {
+		return fmt.Errorf("there is no server download for version '%s'", version.ID)
+	}
+
+	// Check if this exact jar is already installed
+	statePath := filepath.Join(filepath.Dir(path), VanillaFile)
+	saved, err := LoadVanilla(statePath)
+	if err != nil {
+		return fmt.Errorf("unable to read old version: %s", err)
+	}
+	if saved.Version == version.ID && saved.SHA1 == server.SHA1 {
+		return ErrAlreadyUpToDate
+	}
+
+	verify := func(download string) error { return VerifySHA1(download, server.SHA1) }
+	if err = replaceFile(server.URL, path, verify); err != nil {
+		return err
+	}
+
+	install := VanillaInstall{Version: version.ID, SHA1: server.SHA1}
+	if err = install.Save(statePath); err != nil {
+		return fmt.Errorf("unable to save version file: %s", err)
+	}
+
+	return nil
+}
+
+// resolve finds the version in the version manifest.
+func (v Vanilla) resolve() (*VanillaVersion, error) {
+	var manifest VanillaManifest
+	if err := getJSON(vanillaManifest, &manifest); err != nil {
+		return nil, fmt.Errorf("unable to get the version manifest: %s", err)
+	}
+
+	id := v.Version
+	switch id {
+	case "release", "latest":
+		id = manifest.Latest.Release
+	case "snapshot":
+		id = manifest.Latest.Snapshot
+	}
+
+	for _, version := range manifest.Versions {
+		if version.ID == id {
+			return &version, nil
+		}
+	}
+
+	return nil, fmt.Errorf("server version not found: %s", v.Version)
+}
+
+// LoadVanilla reads the installed vanilla version from a file. If the file
+// does not exist, an empty struct and no error is returned.
+func LoadVanilla(path string) (*VanillaInstall, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &VanillaInstall{}, nil
+		}
+		return nil, err
+	}
+
+	var install VanillaInstall
+	if err = json.Unmarshal(raw, &install); err != nil {
+		return nil, err
+	}
+
+	return &install, nil
+}
+
+// Save writes the installed vanilla version to a file.
+func (i VanillaInstall) Save(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return json.NewEncoder(file).Encode(i)
+}
@@ -0,0 +1,135 @@
+package provider
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeMojang serves a version manifest with a release and a snapshot, and
+// returns the jar of each version. The hashes are of the jars as they are
+// when the server starts.
+func fakeMojang(t *testing.T) map[string][]byte {
+	jars := map[string][]byte{
+		"1.20.4": []byte("release jar"),
+		"24w05a": []byte("snapshot jar"),
+	}
+	sums := make(map[string]string)
+	for id, jar := range jars {
+		sum := sha1.Sum(jar)
+		sums[id] = hex.EncodeToString(sum[:])
+	}
+
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/version_manifest_v2.json", func(w http.ResponseWriter, r *http.Request) {
+		manifest := VanillaManifest{}
+		manifest.Latest.Release = "1.20.4"
+		manifest.Latest.Snapshot = "24w05a"
+		for _, id := range []string{"24w05a", "1.20.4", "1.2.5"} {
+			manifest.Versions = append(manifest.Versions, VanillaVersion{ID: id, URL: server.URL + "/versions/" + id + ".json"})
+		}
+		json.NewEncoder(w).Encode(manifest)
+	})
+	mux.HandleFunc("/versions/", func(w http.ResponseWriter, r *http.Request) {
+		id := filepath.Base(r.URL.Path)
+		id = id[:len(id)-len(".json")]
+
+		pkg := VanillaPackage{ID: id}
+		if sum, ok := sums[id]; ok {
+			pkg.Downloads.Server = &VanillaDownload{
+				SHA1: sum,
+				Size: int64(len(jars[id])),
+				URL:  server.URL + "/jars/" + id,
+			}
+		}
+		json.NewEncoder(w).Encode(pkg)
+	})
+	mux.HandleFunc("/jars/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(jars[filepath.Base(r.URL.Path)])
+	})
+
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	old := vanillaManifest
+	vanillaManifest = server.URL + "/version_manifest_v2.json"
+	t.Cleanup(func() { vanillaManifest = old })
+
+	return jars
+}
+
+func TestVanillaDownload(t *testing.T) {
+	jars := fakeMojang(t)
+
+	cases := map[string]string{
+		"release":  "1.20.4",
+		"snapshot": "24w05a",
+		"1.20.4":   "1.20.4",
+	}
+	for version, expected := range cases {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "server.jar")
+		if err := (Vanilla{Version: version}).Download(path); err != nil {
+			t.Fatalf("error downloading '%s': %s\n", version, err)
+		}
+
+		raw, err := os.ReadFile(path)
+		if err != nil || string(raw) != string(jars[expected]) {
+			t.Fatalf("expected the jar for '%s', got '%s' (%v)", expected, raw, err)
+		}
+
+		saved, err := LoadVanilla(filepath.Join(dir, VanillaFile))
+		if err != nil {
+			t.Fatalf("error loading version: %s\n", err)
+		}
+		if saved.Version != expected {
+			t.Fatalf("expected version '%s' to be saved, got '%s'", expected, saved.Version)
+		}
+
+		if err = (Vanilla{Version: version}).Download(path); err != ErrAlreadyUpToDate {
+			t.Fatalf("expected ErrAlreadyUpToDate, got %v", err)
+		}
+	}
+}
+
+func TestVanillaDownloadErrors(t *testing.T) {
+	fakeMojang(t)
+
+	// Unknown versions, and versions from before there was a server download
+	for _, version := range []string{"1.99", "1.2.5"} {
+		if err := (Vanilla{Version: version}).Download(filepath.Join(t.TempDir(), "server.jar")); err == nil {
+			t.Fatalf("expected an error for version '%s'", version)
+		}
+	}
+}
+
+func TestVanillaDownloadHashMismatch(t *testing.T) {
+	jars := fakeMojang(t)
+	jars["1.20.4"] = []byte("tampered jar")
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.jar")
+	if err := os.WriteFile(path, []byte("working jar"), 0644); err != nil {
+		t.Fatalf("error creating server jar: %s\n", err)
+	}
+	if err := (Vanilla{Version: "1.20.4"}).Download(path); err == nil {
+		t.Fatalf("expected an error for a jar with the wrong hash")
+	}
+
+	// The version isn't saved, so the next update tries again
+	if _, err := os.Stat(filepath.Join(dir, VanillaFile)); err == nil {
+		t.Fatalf("expected the version not to be saved")
+	}
+
+	// The working jar is left alone, without the bad download next to it
+	readJar(t, path, "working jar")
+	if _, err := os.Stat(path + ".download"); err == nil {
+		t.Fatalf("expected the download to be removed")
+	}
+}
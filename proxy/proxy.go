@@ -0,0 +1,211 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Types of proxy servers.
+const (
+	Velocity   = "velocity"
+	BungeeCord = "bungeecord"
+)
+
+// Names of the config files that each type of proxy uses.
+const (
+	VelocityFile   = "velocity.toml"
+	BungeeCordFile = "config.yml"
+)
+
+// DefaultPort is the port of a backend server if its address doesn't have one.
+const DefaultPort = 25565
+
+// ErrNotProxy is returned when a server directory doesn't have the config
+// file of a proxy.
+var ErrNotProxy = errors.New("no Velocity or BungeeCord config found")
+
+// Config is the part of a proxy's config that mcsmanager cares about.
+type Config struct {
+	// Type is the type of proxy, either Velocity or BungeeCord.
+	Type string
+
+	// Bind is the address that the proxy listens on for players.
+	Bind string
+
+	// Servers are the addresses of the backend servers that are
+	// registered in the proxy, by the name the proxy gives them.
+	Servers map[string]string
+}
+
+// velocityConfig is the layout of a `velocity.toml` file.
+type velocityConfig struct {
+	Bind    string                 `toml:"bind"`
+	Servers map[string]interface{} `toml:"servers"`
+}
+
+// bungeeConfig is the layout of a BungeeCord or Waterfall `config.yml` file.
+type bungeeConfig struct {
+	Listeners []struct {
+		Host string `yaml:"host"`
+	} `yaml:"listeners"`
+	Servers map[string]struct {
+		Address string `yaml:"address"`
+	} `yaml:"servers"`
+}
+
+// Load reads the proxy config in a server directory. ErrNotProxy is
+// returned if the server isn't a proxy.
+func Load(dir string) (*Config, error) {
+	raw, err := os.ReadFile(filepath.Join(dir, VelocityFile))
+	if err == nil {
+		return readVelocity(raw)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	raw, err = os.ReadFile(filepath.Join(dir, BungeeCordFile))
+	if err == nil {
+		return readBungeeCord(raw)
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotProxy
+	}
+
+	return nil, err
+}
+
+// readVelocity parses a `velocity.toml` file. The servers table also has
+// the `try` list, which isn't a server.
+func readVelocity(raw []byte) (*Config, error) {
+	var file velocityConfig
+	if _, err := toml.Decode(string(raw), &file); err != nil {
+		return nil, fmt.Errorf("unable to read %s: %s", VelocityFile, err)
+	}
+
+	conf := &Config{Type: Velocity, Bind: file.Bind, Servers: make(map[string]string)}
+	for name, value := range file.Servers {
+		if address, ok := value.(string); ok {
+			conf.Servers[name] = address
+		}
+	}
+
+	return conf, nil
+}
+
+// readBungeeCord parses a BungeeCord `config.yml` file. Other servers have a
+// `config.yml` too, so it only counts as a proxy if it has a servers section.
+func readBungeeCord(raw []byte) (*Config, error) {
+	var file bungeeConfig
+	if err := yaml.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("unable to read %s: %s", BungeeCordFile, err)
+	}
+	if file.Servers == nil {
+		return nil, ErrNotProxy
+	}
+
+	conf := &Config{Type: BungeeCord, Servers: make(map[string]string)}
+	if len(file.Listeners) > 0 {
+		conf.Bind = file.Listeners[0].Host
+	}
+	for name, server := range file.Servers {
+		conf.Servers[name] = server.Address
+	}
+
+	return conf, nil
+}
+
+// StopCommand returns the console command that shuts down the proxy.
+func (c *Config) StopCommand() string {
+	if c.Type == BungeeCord {
+		return "end"
+	}
+
+	return "shutdown"
+}
+
+// Names returns the names of the registered servers, sorted.
+func (c *Config) Names() []string {
+	names := make([]string, 0, len(c.Servers))
+	for name := range c.Servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Find looks for a registered server with the given address, and returns
+// the name that the proxy gives it. Ports have to match, but hosts that
+// point to this machine are treated as the same, and a server that listens
+// on every interface matches any host.
+func (c *Config) Find(address string) (string, bool) {
+	for _, name := range c.Names() {
+		if SameAddress(c.Servers[name], address) {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+// SameAddress checks if two server addresses point to the same server.
+func SameAddress(a, b string) bool {
+	hostA, portA := splitAddress(a)
+	hostB, portB := splitAddress(b)
+	if portA != portB {
+		return false
+	}
+
+	if isUnspecified(hostA) || isUnspecified(hostB) {
+		return true
+	}
+	if isLocal(hostA) && isLocal(hostB) {
+		return true
+	}
+
+	return strings.EqualFold(hostA, hostB)
+}
+
+// splitAddress splits an address into its host and port, using the default
+// port if there isn't one.
+func splitAddress(address string) (string, int) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return address, DefaultPort
+	}
+
+	n, err := strconv.Atoi(port)
+	if err != nil {
+		return host, DefaultPort
+	}
+
+	return host, n
+}
+
+// isUnspecified checks if a host means every interface.
+func isUnspecified(host string) bool {
+	if host == "" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsUnspecified()
+}
+
+// isLocal checks if a host is the loopback address.
+func isLocal(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,127 @@
+package proxy
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testVelocity = `
+config-version = "2.6"
+bind = "0.0.0.0:25577"
+
+[servers]
+lobby = "127.0.0.1:30066"
+survival = "10.0.0.5:30067"
+try = ["lobby"]
+
+[forced-hosts]
+"lobby.example.com" = ["lobby"]
+`
+
+const testBungeeCord = `
+listeners:
+- query_port: 25577
+  host: 0.0.0.0:25577
+  motd: '&1Another Bungee server'
+servers:
+  lobby:
+    motd: '&1Just another BungeeCord - Forced Host'
+    address: localhost:25565
+    restricted: false
+`
+
+// writeConfig writes a config file into a new server directory.
+func writeConfig(t *testing.T, name, contents string) string {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+		t.Fatalf("error writing config: %s\n", err)
+	}
+
+	return dir
+}
+
+func TestLoadVelocity(t *testing.T) {
+	conf, err := Load(writeConfig(t, VelocityFile, testVelocity))
+	if err != nil {
+		t.Fatalf("error loading config: %s\n", err)
+	}
+
+	expected := &Config{
+		Type: Velocity,
+		Bind: "0.0.0.0:25577",
+		Servers: map[string]string{
+			"lobby":    "127.0.0.1:30066",
+			"survival": "10.0.0.5:30067",
+		},
+	}
+	if !reflect.DeepEqual(conf, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, conf)
+	}
+	if conf.StopCommand() != "shutdown" {
+		t.Fatalf("expected 'shutdown', got '%s'", conf.StopCommand())
+	}
+}
+
+func TestLoadBungeeCord(t *testing.T) {
+	conf, err := Load(writeConfig(t, BungeeCordFile, testBungeeCord))
+	if err != nil {
+		t.Fatalf("error loading config: %s\n", err)
+	}
+
+	expected := &Config{
+		Type:    BungeeCord,
+		Bind:    "0.0.0.0:25577",
+		Servers: map[string]string{"lobby": "localhost:25565"},
+	}
+	if !reflect.DeepEqual(conf, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, conf)
+	}
+	if conf.StopCommand() != "end" {
+		t.Fatalf("expected 'end', got '%s'", conf.StopCommand())
+	}
+}
+
+func TestLoadNotProxy(t *testing.T) {
+	if _, err := Load(t.TempDir()); err != ErrNotProxy {
+		t.Fatalf("expected ErrNotProxy, got %v", err)
+	}
+
+	// Plugins and other servers use config.yml files too
+	if _, err := Load(writeConfig(t, BungeeCordFile, "debug: false\n")); err != ErrNotProxy {
+		t.Fatalf("expected ErrNotProxy, got %v", err)
+	}
+}
+
+func TestFind(t *testing.T) {
+	conf := &Config{
+		Type: Velocity,
+		Servers: map[string]string{
+			"lobby":    "127.0.0.1:30066",
+			"survival": "10.0.0.5:30067",
+			"creative": "play.example.com",
+		},
+	}
+
+	cases := map[string]string{
+		"127.0.0.1:30066":        "lobby",
+		"localhost:30066":        "lobby",
+		":30066":                 "lobby",
+		"10.0.0.5:30067":         "survival",
+		"0.0.0.0:30067":          "survival",
+		"play.example.com:25565": "creative",
+	}
+	for address, expected := range cases {
+		name, ok := conf.Find(address)
+		if !ok || name != expected {
+			t.Fatalf("expected '%s' for '%s', got '%s'", expected, address, name)
+		}
+	}
+
+	for _, address := range []string{"127.0.0.1:30067", "10.0.0.6:30067", "127.0.0.1:25565"} {
+		if name, ok := conf.Find(address); ok {
+			t.Fatalf("expected no server for '%s', got '%s'", address, name)
+		}
+	}
+}
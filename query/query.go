@@ -0,0 +1,315 @@
+package query
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EbonJaeger/mcsmanager/properties"
+)
+
+// Packet types of the Query protocol.
+const (
+	typeStat      = 0
+	typeHandshake = 9
+)
+
+const (
+	// DefaultPort is the port that the server listens on for queries if
+	// server.properties doesn't set one.
+	DefaultPort = 25565
+
+	// maxPacket is the largest packet that we accept from the server.
+	maxPacket = 64 * 1024
+)
+
+// magic starts every packet that is sent to the server.
+var magic = []byte{0xfe, 0xfd}
+
+// Padding around the sections of a full stat response.
+var (
+	keysPadding    = []byte("splitnum\x00\x80\x00")
+	playersPadding = []byte("\x01player_\x00\x00")
+)
+
+// Settings are the query settings of a server.
+type Settings struct {
+	Enabled bool
+	Address string
+}
+
+// LoadSettings reads the query settings from the server.properties file in
+// a server directory.
+func LoadSettings(dir string) (Settings, error) {
+	props, err := properties.Load(dir)
+	if err != nil {
+		return Settings{}, err
+	}
+
+	host := props.Get("server-ip", "127.0.0.1")
+	port := props.Get("query.port", strconv.Itoa(DefaultPort))
+
+	return Settings{
+		Enabled: props.Get("enable-query", "false") == "true",
+		Address: net.JoinHostPort(host, port),
+	}, nil
+}
+
+// BasicStat is the short status that a server reports.
+type BasicStat struct {
+	MOTD       string
+	GameType   string
+	Map        string
+	NumPlayers int
+	MaxPlayers int
+	HostPort   int
+	HostIP     string
+}
+
+// FullStat is everything that a server reports, including the names of
+// all online players and its plugins.
+type FullStat struct {
+	MOTD       string
+	GameType   string
+	GameID     string
+	Version    string
+	Plugins    string
+	Map        string
+	NumPlayers int
+	MaxPlayers int
+	HostPort   int
+	HostIP     string
+	Players    []string
+}
+
+// Software returns the server software and its plugins from the plugin
+// list, e.g. "Paper on 1.20.4: LuckPerms 5.4; Vault 1.7". Vanilla servers
+// don't report any software or plugins.
+func (s FullStat) Software() (string, []string) {
+	if s.Plugins == "" {
+		return "", []string{}
+	}
+
+	parts := strings.SplitN(s.Plugins, ":", 2)
+	plugins := make([]string, 0)
+	if len(parts) == 2 {
+		for _, plugin := range strings.Split(parts[1], ";") {
+			if plugin = strings.TrimSpace(plugin); plugin != "" {
+				plugins = append(plugins, plugin)
+			}
+		}
+	}
+
+	return strings.TrimSpace(parts[0]), plugins
+}
+
+// Client asks a server for its status with the Query protocol.
+type Client struct {
+	conn    net.Conn
+	session int32
+	token   int32
+	timeout time.Duration
+}
+
+// Dial sets up a query session with a server. Queries are sent over UDP,
+// so an error is only returned here if the server doesn't answer.
+func Dial(address string, timeout time.Duration) (*Client, error) {
+	conn, err := net.DialTimeout("udp", address, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	// The server only looks at the lower 4 bits of each byte
+	c := &Client{conn: conn, session: rand.Int31() & 0x0f0f0f0f, timeout: timeout}
+	if err = c.handshake(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Close closes the connection to the server.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Basic asks the server for its basic stat.
+func (c *Client) Basic() (*BasicStat, error) {
+	r, err := c.request(typeStat, c.challenge())
+	if err != nil {
+		return nil, err
+	}
+
+	stat := &BasicStat{}
+	fields := make([]string, 5)
+	for i := range fields {
+		if fields[i], err = readString(r); err != nil {
+			return nil, err
+		}
+	}
+	stat.MOTD, stat.GameType, stat.Map = fields[0], fields[1], fields[2]
+	stat.NumPlayers, _ = strconv.Atoi(fields[3])
+	stat.MaxPlayers, _ = strconv.Atoi(fields[4])
+
+	// The port is the only little endian number in the protocol
+	var port uint16
+	if err = binary.Read(r, binary.LittleEndian, &port); err != nil {
+		return nil, err
+	}
+	stat.HostPort = int(port)
+
+	if stat.HostIP, err = readString(r); err != nil {
+		return nil, err
+	}
+
+	return stat, nil
+}
+
+// Full asks the server for its full stat.
+func (c *Client) Full() (*FullStat, error) {
+	payload := append(c.challenge(), 0, 0, 0, 0)
+	r, err := c.request(typeStat, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = skip(r, keysPadding); err != nil {
+		return nil, err
+	}
+
+	// Key and value pairs, ending with an empty key
+	values := make(map[string]string)
+	for {
+		key, err := readString(r)
+		if err != nil {
+			return nil, err
+		}
+		if key == "" {
+			break
+		}
+		if values[key], err = readString(r); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = skip(r, playersPadding); err != nil {
+		return nil, err
+	}
+
+	// Player names, ending with an empty name
+	players := make([]string, 0)
+	for {
+		name, err := readString(r)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			break
+		}
+		players = append(players, name)
+	}
+
+	stat := &FullStat{
+		MOTD:     values["hostname"],
+		GameType: values["gametype"],
+		GameID:   values["game_id"],
+		Version:  values["version"],
+		Plugins:  values["plugins"],
+		Map:      values["map"],
+		HostIP:   values["hostip"],
+		Players:  players,
+	}
+	stat.NumPlayers, _ = strconv.Atoi(values["numplayers"])
+	stat.MaxPlayers, _ = strconv.Atoi(values["maxplayers"])
+	stat.HostPort, _ = strconv.Atoi(values["hostport"])
+
+	return stat, nil
+}
+
+// handshake gets a challenge token from the server.
+func (c *Client) handshake() error {
+	r, err := c.request(typeHandshake, nil)
+	if err != nil {
+		return err
+	}
+
+	raw, err := readString(r)
+	if err != nil {
+		return err
+	}
+	token, err := strconv.ParseInt(raw, 10, 32)
+	if err != nil {
+		return fmt.Errorf("query: bad challenge token '%s'", raw)
+	}
+	c.token = int32(token)
+
+	return nil
+}
+
+// challenge returns the challenge token to send with a stat request.
+func (c *Client) challenge() []byte {
+	token := make([]byte, 4)
+	binary.BigEndian.PutUint32(token, uint32(c.token))
+	return token
+}
+
+// request sends a packet to the server, and returns a reader for the body
+// of its response.
+func (c *Client) request(packetType byte, payload []byte) (*bytes.Reader, error) {
+	var packet bytes.Buffer
+	packet.Write(magic)
+	packet.WriteByte(packetType)
+	binary.Write(&packet, binary.BigEndian, c.session)
+	packet.Write(payload)
+
+	c.conn.SetDeadline(time.Now().Add(c.timeout))
+	if _, err := c.conn.Write(packet.Bytes()); err != nil {
+		return nil, err
+	}
+
+	raw := make([]byte, maxPacket)
+	n, err := c.conn.Read(raw)
+	if err != nil {
+		return nil, err
+	}
+	if n < 5 {
+		return nil, errors.New("query: response is too short")
+	}
+	if raw[0] != packetType || int32(binary.BigEndian.Uint32(raw[1:5])) != c.session {
+		return nil, errors.New("query: unexpected response from server")
+	}
+
+	return bytes.NewReader(raw[5:n]), nil
+}
+
+// readString reads a null-terminated string.
+func readString(r *bytes.Reader) (string, error) {
+	var sb strings.Builder
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return "", errors.New("query: response ended early")
+		}
+		if b == 0 {
+			return sb.String(), nil
+		}
+		sb.WriteByte(b)
+	}
+}
+
+// skip reads past the padding in a response.
+func skip(r *bytes.Reader, padding []byte) error {
+	raw := make([]byte, len(padding))
+	if _, err := r.Read(raw); err != nil || !bytes.Equal(raw, padding) {
+		return errors.New("query: unexpected response from server")
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+package query
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const fakeToken = 9513307
+
+// fakeServer answers queries the same way as a Minecraft server.
+func fakeServer(t *testing.T) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error starting fake server: %s\n", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		raw := make([]byte, 1024)
+		for {
+			n, addr, err := conn.ReadFrom(raw)
+			if err != nil {
+				return
+			}
+			packet := raw[:n]
+			if !bytes.HasPrefix(packet, magic) {
+				continue
+			}
+			packetType, session := packet[2], packet[3:7]
+
+			var response bytes.Buffer
+			response.WriteByte(packetType)
+			response.Write(session)
+
+			switch {
+			case packetType == typeHandshake:
+				response.WriteString("9513307\x00")
+			case binary.BigEndian.Uint32(packet[7:11]) != fakeToken:
+				continue
+			case len(packet) == 11:
+				response.WriteString("A Minecraft Server\x00SMP\x00world\x002\x0020\x00")
+				binary.Write(&response, binary.LittleEndian, uint16(25565))
+				response.WriteString("127.0.0.1\x00")
+			default:
+				response.Write(keysPadding)
+				for _, kv := range [][2]string{
+					{"hostname", "A Minecraft Server"},
+					{"gametype", "SMP"},
+					{"game_id", "MINECRAFT"},
+					{"version", "1.20.4"},
+					{"plugins", "Paper on 1.20.4: LuckPerms 5.4.102; Vault 1.7.3"},
+					{"map", "world"},
+					{"numplayers", "2"},
+					{"maxplayers", "20"},
+					{"hostport", "25565"},
+					{"hostip", "127.0.0.1"},
+				} {
+					response.WriteString(kv[0] + "\x00" + kv[1] + "\x00")
+				}
+				response.WriteByte(0)
+				response.Write(playersPadding)
+				response.WriteString("Alice\x00Bob\x00\x00")
+			}
+
+			conn.WriteTo(response.Bytes(), addr)
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func TestBasic(t *testing.T) {
+	client, err := Dial(fakeServer(t), 5*time.Second)
+	if err != nil {
+		t.Fatalf("error connecting: %s\n", err)
+	}
+	defer client.Close()
+
+	stat, err := client.Basic()
+	if err != nil {
+		t.Fatalf("error getting basic stat: %s\n", err)
+	}
+
+	expected := BasicStat{
+		MOTD:       "A Minecraft Server",
+		GameType:   "SMP",
+		Map:        "world",
+		NumPlayers: 2,
+		MaxPlayers: 20,
+		HostPort:   25565,
+		HostIP:     "127.0.0.1",
+	}
+	if *stat != expected {
+		t.Fatalf("expected %+v, got %+v", expected, *stat)
+	}
+}
+
+func TestFull(t *testing.T) {
+	client, err := Dial(fakeServer(t), 5*time.Second)
+	if err != nil {
+		t.Fatalf("error connecting: %s\n", err)
+	}
+	defer client.Close()
+
+	stat, err := client.Full()
+	if err != nil {
+		t.Fatalf("error getting full stat: %s\n", err)
+	}
+
+	if stat.Map != "world" || stat.Version != "1.20.4" || stat.NumPlayers != 2 || stat.HostPort != 25565 {
+		t.Fatalf("wrong stat: %+v", *stat)
+	}
+	if !reflect.DeepEqual(stat.Players, []string{"Alice", "Bob"}) {
+		t.Fatalf("wrong players: %v", stat.Players)
+	}
+
+	software, plugins := stat.Software()
+	if software != "Paper on 1.20.4" {
+		t.Fatalf("wrong software: '%s'", software)
+	}
+	if !reflect.DeepEqual(plugins, []string{"LuckPerms 5.4.102", "Vault 1.7.3"}) {
+		t.Fatalf("wrong plugins: %v", plugins)
+	}
+}
+
+func TestSoftwareVanilla(t *testing.T) {
+	software, plugins := FullStat{}.Software()
+	if software != "" || len(plugins) != 0 {
+		t.Fatalf("expected no software or plugins, got '%s' and %v", software, plugins)
+	}
+}
+
+func TestLoadSettings(t *testing.T) {
+	dir := t.TempDir()
+	props := "enable-query=true\nquery.port=25570\n"
+	if err := os.WriteFile(filepath.Join(dir, "server.properties"), []byte(props), 0644); err != nil {
+		t.Fatalf("error creating server.properties: %s\n", err)
+	}
+
+	settings, err := LoadSettings(dir)
+	if err != nil {
+		t.Fatalf("error loading settings: %s\n", err)
+	}
+
+	expected := Settings{Enabled: true, Address: "127.0.0.1:25570"}
+	if settings != expected {
+		t.Fatalf("wrong settings: expected %+v, got %+v", expected, settings)
+	}
+}
@@ -0,0 +1,233 @@
+package rcon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/EbonJaeger/mcsmanager/properties"
+)
+
+// Packet types of the RCON protocol.
+const (
+	typeResponse = 0
+	typeCommand  = 2
+	typeLogin    = 3
+
+	// typeSentinel isn't a real packet type. The server answers it with
+	// an error message, which tells us that it has sent every packet of
+	// the response to the command before it.
+	typeSentinel = 100
+)
+
+const (
+	// DefaultPort is the port that the server listens on for RCON
+	// connections if server.properties doesn't set one.
+	DefaultPort = 25575
+
+	// maxPayload is the largest body that the server accepts in a packet.
+	maxPayload = 1446
+
+	// maxPacket is the largest packet that we accept from the server.
+	maxPacket = 4096 + 10
+
+	// timeout is how long to wait for the server to respond.
+	timeout = 10 * time.Second
+)
+
+// ErrAuthFailed is returned when the server doesn't accept the password.
+var ErrAuthFailed = errors.New("rcon: wrong password")
+
+// Settings are the RCON settings of a server.
+type Settings struct {
+	Enabled  bool
+	Address  string
+	Password string
+}
+
+// LoadSettings reads the RCON settings from the server.properties file in a
+// server directory. RCON is only enabled if `enable-rcon` is true and there
+// is a password, since the server doesn't start RCON without one.
+func LoadSettings(dir string) (Settings, error) {
+	props, err := properties.Load(dir)
+	if err != nil {
+		return Settings{}, err
+	}
+
+	host := props.Get("server-ip", "127.0.0.1")
+	port := props.Get("rcon.port", strconv.Itoa(DefaultPort))
+	password := props.Get("rcon.password", "")
+
+	return Settings{
+		Enabled:  props.Get("enable-rcon", "false") == "true" && password != "",
+		Address:  net.JoinHostPort(host, port),
+		Password: password,
+	}, nil
+}
+
+// Client is a connection to a server's RCON port. It is safe to use
+// from multiple goroutines, but commands are sent one at a time.
+type Client struct {
+	sync.Mutex
+	conn   net.Conn
+	nextID int32
+}
+
+// Dial connects to a server and logs in with the given password.
+func Dial(address, password string) (*Client, error) {
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{conn: conn, nextID: 1}
+	if err = c.login(password); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Close closes the connection to the server.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Execute runs a command on the server, and returns its response. Long
+// responses that the server splits into several packets are put back
+// together.
+//
+// The server closes the connection when it stops, so that is only treated
+// as success for the stop command.
+func (c *Client) Execute(command string) (string, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if len(command) > maxPayload {
+		return "", fmt.Errorf("rcon: command is too long, %d > %d bytes", len(command), maxPayload)
+	}
+
+	id, err := c.send(typeCommand, command)
+	if err != nil {
+		return "", err
+	}
+
+	stopping := strings.TrimPrefix(strings.TrimSpace(command), "/") == "stop"
+
+	// The server only reads one packet at a time, and throws away anything
+	// else that arrived with it, so wait for the response before marking
+	// the end of it
+	var response bytes.Buffer
+	for {
+		packetID, _, body, err := c.read()
+		if err != nil {
+			if stopping && (errors.Is(err, io.EOF) || errors.Is(err, syscall.ECONNRESET)) {
+				return "", nil
+			}
+			return "", err
+		}
+		if packetID == id {
+			response.WriteString(body)
+			break
+		}
+	}
+
+	// Nothing else comes from a server that is stopping
+	if stopping {
+		return response.String(), nil
+	}
+
+	sentinel, err := c.send(typeSentinel, "")
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		packetID, _, body, err := c.read()
+		if err != nil {
+			return "", err
+		}
+
+		switch packetID {
+		case id:
+			response.WriteString(body)
+		case sentinel:
+			return response.String(), nil
+		}
+	}
+}
+
+// login authenticates with the server.
+func (c *Client) login(password string) error {
+	id, err := c.send(typeLogin, password)
+	if err != nil {
+		return err
+	}
+
+	for {
+		packetID, packetType, _, err := c.read()
+		if err != nil {
+			return err
+		}
+
+		// The server sends a failed login with an ID of -1
+		if packetID == -1 {
+			return ErrAuthFailed
+		}
+		if packetID == id && packetType == typeCommand {
+			return nil
+		}
+	}
+}
+
+// send writes a packet to the server, returning its ID.
+func (c *Client) send(packetType int32, body string) (int32, error) {
+	id := c.nextID
+	c.nextID++
+
+	// Length, ID, type, body, and two null bytes
+	var packet bytes.Buffer
+	binary.Write(&packet, binary.LittleEndian, int32(4+4+len(body)+2))
+	binary.Write(&packet, binary.LittleEndian, id)
+	binary.Write(&packet, binary.LittleEndian, packetType)
+	packet.WriteString(body)
+	packet.Write([]byte{0, 0})
+
+	c.conn.SetWriteDeadline(time.Now().Add(timeout))
+	_, err := c.conn.Write(packet.Bytes())
+	return id, err
+}
+
+// read reads a single packet from the server.
+func (c *Client) read() (id, packetType int32, body string, err error) {
+	c.conn.SetReadDeadline(time.Now().Add(timeout))
+
+	var length int32
+	if err = binary.Read(c.conn, binary.LittleEndian, &length); err != nil {
+		return
+	}
+	if length < 10 || length > maxPacket {
+		err = fmt.Errorf("rcon: bad packet length %d", length)
+		return
+	}
+
+	raw := make([]byte, length)
+	if _, err = io.ReadFull(c.conn, raw); err != nil {
+		return
+	}
+
+	id = int32(binary.LittleEndian.Uint32(raw[0:4]))
+	packetType = int32(binary.LittleEndian.Uint32(raw[4:8]))
+	body = string(bytes.TrimRight(raw[8:], "\x00"))
+
+	return
+}
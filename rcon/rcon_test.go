@@ -0,0 +1,234 @@
+package rcon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeServer is a tiny RCON server that answers commands the same way
+// as a Minecraft server, splitting long responses into several packets.
+// Like the server, it handles one packet per read and throws away the
+// rest of what it read.
+type fakeServer struct {
+	listener net.Listener
+	password string
+	handler  func(command string) string
+
+	// delay is how long to wait before each read, so packets sent right
+	// after each other arrive together
+	delay time.Duration
+}
+
+func newFakeServer(t *testing.T, password string, handler func(string) string) *fakeServer {
+	return newSlowServer(t, password, handler, 0)
+}
+
+func newSlowServer(t *testing.T, password string, handler func(string) string, delay time.Duration) *fakeServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error starting fake server: %s\n", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	s := &fakeServer{listener: listener, password: password, handler: handler, delay: delay}
+	go s.serve()
+	return s
+}
+
+func (s *fakeServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	authed := false
+	buf := make([]byte, maxPacket+4)
+	for {
+		time.Sleep(s.delay)
+		n, err := conn.Read(buf)
+		if err != nil {
+			return
+		}
+		length := int(binary.LittleEndian.Uint32(buf[0:4]))
+		if n < 14 || length+4 > n {
+			return
+		}
+		raw := buf[4 : 4+length]
+		id := int32(binary.LittleEndian.Uint32(raw[0:4]))
+		packetType := int32(binary.LittleEndian.Uint32(raw[4:8]))
+		body := string(bytes.TrimRight(raw[8:], "\x00"))
+
+		switch {
+		case packetType == typeLogin:
+			if body == s.password {
+				authed = true
+				writePacket(conn, id, typeCommand, "")
+			} else {
+				writePacket(conn, -1, typeCommand, "")
+			}
+		case !authed:
+			return
+		case packetType == typeCommand:
+			response := s.handler(body)
+			if body == "stop" || body == "crash" {
+				return
+			}
+			for len(response) > 4096 {
+				writePacket(conn, id, typeResponse, response[:4096])
+				response = response[4096:]
+			}
+			writePacket(conn, id, typeResponse, response)
+		default:
+			writePacket(conn, id, typeResponse, fmt.Sprintf("Unknown request %x", packetType))
+		}
+	}
+}
+
+func writePacket(w io.Writer, id, packetType int32, body string) {
+	var packet bytes.Buffer
+	binary.Write(&packet, binary.LittleEndian, int32(10+len(body)))
+	binary.Write(&packet, binary.LittleEndian, id)
+	binary.Write(&packet, binary.LittleEndian, packetType)
+	packet.WriteString(body)
+	packet.Write([]byte{0, 0})
+	w.Write(packet.Bytes())
+}
+
+func TestExecute(t *testing.T) {
+	server := newFakeServer(t, "secret", func(command string) string {
+		return "You said: " + command
+	})
+
+	client, err := Dial(server.listener.Addr().String(), "secret")
+	if err != nil {
+		t.Fatalf("error connecting: %s\n", err)
+	}
+	defer client.Close()
+
+	for _, command := range []string{"list", "say hi"} {
+		response, err := client.Execute(command)
+		if err != nil {
+			t.Fatalf("error running command: %s\n", err)
+		}
+		if response != "You said: "+command {
+			t.Fatalf("wrong response: '%s'", response)
+		}
+	}
+}
+
+func TestExecuteLongResponse(t *testing.T) {
+	long := strings.Repeat("abcdefgh", 1500)
+	server := newFakeServer(t, "secret", func(command string) string {
+		return long
+	})
+
+	client, err := Dial(server.listener.Addr().String(), "secret")
+	if err != nil {
+		t.Fatalf("error connecting: %s\n", err)
+	}
+	defer client.Close()
+
+	response, err := client.Execute("help")
+	if err != nil {
+		t.Fatalf("error running command: %s\n", err)
+	}
+	if response != long {
+		t.Fatalf("wrong response: expected %d bytes, got %d", len(long), len(response))
+	}
+}
+
+func TestExecuteStop(t *testing.T) {
+	server := newFakeServer(t, "secret", func(command string) string {
+		return ""
+	})
+
+	client, err := Dial(server.listener.Addr().String(), "secret")
+	if err != nil {
+		t.Fatalf("error connecting: %s\n", err)
+	}
+	defer client.Close()
+
+	if _, err := client.Execute("stop"); err != nil {
+		t.Fatalf("server closing the connection should not be an error: %s\n", err)
+	}
+}
+
+func TestExecuteBatchedPackets(t *testing.T) {
+	// Anything the client sends without waiting arrives in one read
+	server := newSlowServer(t, "secret", func(command string) string {
+		return "You said: " + command
+	}, 50*time.Millisecond)
+
+	client, err := Dial(server.listener.Addr().String(), "secret")
+	if err != nil {
+		t.Fatalf("error connecting: %s\n", err)
+	}
+	defer client.Close()
+
+	response, err := client.Execute("list")
+	if err != nil {
+		t.Fatalf("error running command: %s\n", err)
+	}
+	if response != "You said: list" {
+		t.Fatalf("wrong response: '%s'", response)
+	}
+}
+
+func TestExecuteClosed(t *testing.T) {
+	server := newFakeServer(t, "secret", func(command string) string {
+		return ""
+	})
+
+	client, err := Dial(server.listener.Addr().String(), "secret")
+	if err != nil {
+		t.Fatalf("error connecting: %s\n", err)
+	}
+	defer client.Close()
+
+	if _, err := client.Execute("crash"); err == nil {
+		t.Fatalf("expected an error when the server closes the connection")
+	}
+}
+
+func TestWrongPassword(t *testing.T) {
+	server := newFakeServer(t, "secret", func(command string) string {
+		return ""
+	})
+
+	if _, err := Dial(server.listener.Addr().String(), "wrong"); err != ErrAuthFailed {
+		t.Fatalf("expected ErrAuthFailed, got %v", err)
+	}
+}
+
+func TestLoadSettings(t *testing.T) {
+	dir := t.TempDir()
+	props := "enable-rcon=true\nrcon.port=25580\nrcon.password=secret\nserver-ip=\n"
+	if err := os.WriteFile(filepath.Join(dir, "server.properties"), []byte(props), 0644); err != nil {
+		t.Fatalf("error creating server.properties: %s\n", err)
+	}
+
+	settings, err := LoadSettings(dir)
+	if err != nil {
+		t.Fatalf("error loading settings: %s\n", err)
+	}
+
+	expected := Settings{Enabled: true, Address: "127.0.0.1:25580", Password: "secret"}
+	if settings != expected {
+		t.Fatalf("wrong settings: expected %+v, got %+v", expected, settings)
+	}
+}
@@ -0,0 +1,524 @@
+package mcsmanager
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cheggaaa/pb/v3"
+)
+
+// RepositoryDir is the name of the directory inside of the backup
+// directory that holds the backup repository.
+const RepositoryDir = "repository"
+
+// chunkSize is the size that files are split into before being stored.
+// Region files are modified in place, so fixed-size chunks let unchanged
+// parts of a region be shared between snapshots.
+const chunkSize = 1024 * 1024
+
+// Repository is a content-addressed backup store. Files are split into chunks
+// that are stored by the hash of their contents, so a chunk that is in more
+// than one snapshot is only stored once. Each snapshot has a manifest that
+// lists the chunks that make up each file.
+type Repository struct {
+	Path string
+}
+
+// Snapshot is the manifest of a single backup in a repository.
+type Snapshot struct {
+	ID    string         `json:"id"`
+	Time  time.Time      `json:"time"`
+	Files []SnapshotFile `json:"files"`
+}
+
+// SnapshotFile describes a file, directory, or symlink in a snapshot.
+type SnapshotFile struct {
+	Path    string      `json:"path"`
+	Mode    fs.FileMode `json:"mode"`
+	ModTime time.Time   `json:"mod_time"`
+	Size    int64       `json:"size"`
+	Link    string      `json:"link,omitempty"`
+	Chunks  []string    `json:"chunks,omitempty"`
+}
+
+// OpenRepository opens the backup repository at the given path,
+// creating it if it doesn't exist yet.
+func OpenRepository(path string) (*Repository, error) {
+	r := &Repository{Path: path}
+	for _, dir := range []string{r.chunksDir(), r.snapshotsDir()} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// Snapshot stores all of the files in the given path in the repository,
+// and saves a new snapshot manifest for them.
+func (r *Repository) Snapshot(path string, exclusions ...string) (*Snapshot, error) {
+	// Count the number of files to store
+	count, err := CountFiles(path, exclusions...)
+	if err != nil {
+		return nil, fmt.Errorf("error counting files: %s", err)
+	}
+
+	bar := pb.New(count)
+	bar.SetTemplate(pb.Simple)
+	bar.Set(pb.CleanOnFinish, true)
+	bar.SetWriter(os.Stderr)
+	bar.SetMaxWidth(80)
+	bar.Start()
+
+	matcher, err := LoadExclusions(path, exclusions...)
+	if err != nil {
+		return nil, fmt.Errorf("error reading exclusions: %s", err)
+	}
+
+	now := time.Now()
+	snapshot := &Snapshot{
+		ID:    now.Format(TimeFormat),
+		Time:  now,
+		Files: make([]SnapshotFile, 0),
+	}
+
+	err = fs.WalkDir(os.DirFS(path), ".", func(child string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if child == "." {
+			return nil
+		}
+
+		// Don't store files or directories that should be excluded
+		if matcher.Match(child, entry.IsDir()) {
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		file := SnapshotFile{
+			Path:    child,
+			Mode:    info.Mode(),
+			ModTime: info.ModTime(),
+		}
+
+		switch {
+		case info.Mode().IsDir():
+		case info.Mode()&fs.ModeSymlink != 0:
+			if file.Link, err = os.Readlink(filepath.Join(path, child)); err != nil {
+				return err
+			}
+		case info.Mode().IsRegular():
+			if file.Chunks, file.Size, err = r.storeFile(filepath.Join(path, child)); err != nil {
+				return fmt.Errorf("error storing '%s': %s", child, err)
+			}
+			bar.Increment()
+		default:
+			// Skip sockets, devices, etc.
+			return nil
+		}
+
+		snapshot.Files = append(snapshot.Files, file)
+		return nil
+	})
+
+	bar.Finish()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = r.saveSnapshot(snapshot); err != nil {
+		return nil, fmt.Errorf("unable to save snapshot manifest: %s", err)
+	}
+
+	return snapshot, nil
+}
+
+// Snapshots loads every snapshot manifest in the repository. The returned
+// list is sorted so the oldest snapshot is first.
+func (r *Repository) Snapshots() ([]*Snapshot, error) {
+	entries, err := os.ReadDir(r.snapshotsDir())
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]*Snapshot, 0)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		snapshot, err := r.loadSnapshot(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			return nil, fmt.Errorf("unable to read snapshot '%s': %s", entry.Name(), err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Time.Before(snapshots[j].Time)
+	})
+
+	return snapshots, nil
+}
+
+// Backups returns the snapshots in the repository as a list of backups,
+// sorted so the oldest snapshot is first. The size of each backup is the
+// total size of the files in it.
+func (r *Repository) Backups() ([]Backup, error) {
+	snapshots, err := r.Snapshots()
+	if err != nil {
+		return nil, err
+	}
+
+	backups := make([]Backup, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		var size int64
+		for _, file := range snapshot.Files {
+			size += file.Size
+		}
+
+		backups = append(backups, Backup{
+			Name: snapshot.ID,
+			Path: r.snapshotPath(snapshot.ID),
+			Time: snapshot.Time,
+			Size: size,
+		})
+	}
+
+	return backups, nil
+}
+
+// RestoreSnapshot writes all of the files in a snapshot to the given directory.
+// Every chunk is checked against its hash while it is read. Files that would
+// be written outside of the directory, or through a symlink, are rejected.
+func (r *Repository) RestoreSnapshot(id, dir string) error {
+	snapshot, err := r.loadSnapshot(id)
+	if err != nil {
+		return err
+	}
+
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range snapshot.Files {
+		path, err := safePath(root, file.Path)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case file.Mode.IsDir():
+			if err = os.MkdirAll(path, file.Mode.Perm()); err != nil {
+				return err
+			}
+			continue
+		case file.Mode&fs.ModeSymlink != 0:
+			if err = os.Symlink(file.Link, path); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err = r.restoreFile(file, path); err != nil {
+			return fmt.Errorf("error restoring '%s': %s", file.Path, err)
+		}
+		if err = os.Chtimes(path, file.ModTime, file.ModTime); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Verify reads every chunk of every file in a snapshot, checking that
+// the chunks exist and still match their hashes.
+func (r *Repository) Verify(id string) (*Verification, error) {
+	snapshot, err := r.loadSnapshot(id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Verification{}
+	for _, file := range snapshot.Files {
+		for _, hash := range file.Chunks {
+			if _, err := os.Stat(r.chunkPath(hash)); err != nil {
+				result.Missing = append(result.Missing, file.Path)
+				break
+			}
+			if _, err := r.readChunk(hash); err != nil {
+				result.Corrupt = append(result.Corrupt, file.Path)
+				break
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// Prune removes snapshots that are older than the max age in days, and then
+// the oldest snapshots until there are at most maxSnapshots left. Either limit
+// can be disabled by passing -1, and the number of snapshots by passing 0 as
+// well. Chunks that are no longer used by any of the
+// remaining snapshots are removed afterwards.
+func (r *Repository) Prune(maxSnapshots, maxAge int) (snapshots int, chunks int, err error) {
+	all, err := r.Snapshots()
+	if err != nil {
+		return
+	}
+
+	keep := make([]*Snapshot, 0, len(all))
+	for _, snapshot := range all {
+		if maxAge != -1 && time.Since(snapshot.Time).Hours() > float64(maxAge*24) {
+			if err = r.removeSnapshot(snapshot.ID); err != nil {
+				return
+			}
+			snapshots++
+			continue
+		}
+		keep = append(keep, snapshot)
+	}
+
+	if maxSnapshots > 0 { // -1 or 0 to disable the limit
+		for len(keep) > maxSnapshots {
+			if err = r.removeSnapshot(keep[0].ID); err != nil {
+				return
+			}
+			snapshots++
+			keep = keep[1:]
+		}
+	}
+
+	chunks, err = r.collectGarbage(keep)
+	return
+}
+
+// collectGarbage removes every chunk that isn't referenced by one of the
+// given snapshots.
+func (r *Repository) collectGarbage(snapshots []*Snapshot) (total int, err error) {
+	used := make(map[string]bool)
+	for _, snapshot := range snapshots {
+		for _, file := range snapshot.Files {
+			for _, hash := range file.Chunks {
+				used[hash] = true
+			}
+		}
+	}
+
+	err = filepath.WalkDir(r.chunksDir(), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || used[entry.Name()] {
+			return nil
+		}
+
+		if err = os.Remove(path); err != nil {
+			return err
+		}
+		total++
+		return nil
+	})
+
+	return
+}
+
+// storeFile splits a file into chunks and stores any chunks that aren't
+// already in the repository. The hashes of the chunks are returned in order,
+// along with the size of the file.
+func (r *Repository) storeFile(path string) (hashes []string, size int64, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	hashes = make([]string, 0)
+	buf := make([]byte, chunkSize)
+	for {
+		n, readErr := io.ReadFull(file, buf)
+		if n > 0 {
+			var hash string
+			if hash, err = r.storeChunk(buf[:n]); err != nil {
+				return
+			}
+			hashes = append(hashes, hash)
+			size += int64(n)
+		}
+
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			err = readErr
+			return
+		}
+	}
+
+	return
+}
+
+// storeChunk writes a compressed chunk to the repository if a chunk with the
+// same contents isn't stored yet, returning the hash of the chunk.
+func (r *Repository) storeChunk(data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	path := r.chunkPath(hash)
+	if _, err := os.Stat(path); err == nil {
+		return hash, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+
+	// Write to a temporary file first so a crash can't leave a broken chunk
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".chunk-")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	w := gzip.NewWriter(tmp)
+	if _, err = w.Write(data); err != nil {
+		return "", err
+	}
+	if err = w.Close(); err != nil {
+		return "", err
+	}
+	if err = tmp.Close(); err != nil {
+		return "", err
+	}
+
+	return hash, os.Rename(tmp.Name(), path)
+}
+
+// restoreFile rebuilds a file from its chunks.
+func (r *Repository) restoreFile(file SnapshotFile, path string) error {
+	out, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, file.Mode.Perm())
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	for _, hash := range file.Chunks {
+		data, err := r.readChunk(hash)
+		if err != nil {
+			return err
+		}
+		if _, err = out.Write(data); err != nil {
+			return err
+		}
+	}
+
+	return out.Close()
+}
+
+// readChunk reads and decompresses a chunk, making sure its contents
+// still match its hash.
+func (r *Repository) readChunk(hash string) ([]byte, error) {
+	file, err := os.Open(r.chunkPath(hash))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	if actual := hex.EncodeToString(sum[:]); actual != hash {
+		return nil, fmt.Errorf("chunk is corrupt: expected hash %s, got %s", hash, actual)
+	}
+
+	return data, nil
+}
+
+// saveSnapshot writes a snapshot manifest to disk.
+func (r *Repository) saveSnapshot(snapshot *Snapshot) error {
+	file, err := os.Create(r.snapshotPath(snapshot.ID))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err = json.NewEncoder(file).Encode(snapshot); err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+// loadSnapshot reads a snapshot manifest from disk.
+func (r *Repository) loadSnapshot(id string) (*Snapshot, error) {
+	file, err := os.Open(r.snapshotPath(id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("snapshot not found: %s", id)
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	snapshot := &Snapshot{}
+	if err = json.NewDecoder(file).Decode(snapshot); err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// removeSnapshot deletes a snapshot manifest. Its chunks are left
+// for the garbage collector.
+func (r *Repository) removeSnapshot(id string) error {
+	return os.Remove(r.snapshotPath(id))
+}
+
+func (r *Repository) chunksDir() string {
+	return filepath.Join(r.Path, "chunks")
+}
+
+func (r *Repository) snapshotsDir() string {
+	return filepath.Join(r.Path, "snapshots")
+}
+
+// chunkPath returns the path to a chunk. Chunks are spread out over
+// subdirectories by the first two characters of their hash so that
+// no single directory gets too big.
+func (r *Repository) chunkPath(hash string) string {
+	return filepath.Join(r.chunksDir(), hash[:2], hash)
+}
+
+func (r *Repository) snapshotPath(id string) string {
+	return filepath.Join(r.snapshotsDir(), id+".json")
+}
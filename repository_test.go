@@ -0,0 +1,197 @@
+package mcsmanager
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// countChunks counts the chunks stored in a repository.
+func countChunks(t *testing.T, repo *Repository) (count int) {
+	err := filepath.WalkDir(repo.chunksDir(), func(path string, entry fs.DirEntry, err error) error {
+		if !entry.IsDir() {
+			count++
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("error counting chunks: %s\n", err)
+	}
+
+	return
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	// Given
+	dir := t.TempDir()
+	if err := setupTestDir(dir); err != nil {
+		t.Fatalf("error creating test dir: %s\n", err)
+	}
+
+	repo, err := OpenRepository(filepath.Join(t.TempDir(), "repo"))
+	if err != nil {
+		t.Fatalf("error opening repository: %s\n", err)
+	}
+
+	// When
+	snapshot, err := repo.Snapshot(dir)
+	if err != nil {
+		t.Fatalf("error creating snapshot: %s\n", err)
+	}
+
+	restorePath := t.TempDir()
+	if err = repo.RestoreSnapshot(snapshot.ID, restorePath); err != nil {
+		t.Fatalf("error restoring snapshot: %s\n", err)
+	}
+
+	// Then
+	if err = verifyFiles(dir, restorePath); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSnapshotDeduplicates(t *testing.T) {
+	// Given
+	dir := t.TempDir()
+	if err := setupTestDir(dir); err != nil {
+		t.Fatalf("error creating test dir: %s\n", err)
+	}
+
+	repo, err := OpenRepository(filepath.Join(t.TempDir(), "repo"))
+	if err != nil {
+		t.Fatalf("error opening repository: %s\n", err)
+	}
+
+	// When
+	if _, err = repo.Snapshot(dir); err != nil {
+		t.Fatalf("error creating snapshot: %s\n", err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, "file1.txt"), []byte("changed contents"), 0644); err != nil {
+		t.Fatalf("error changing test file: %s\n", err)
+	}
+	if _, err = repo.Snapshot(dir); err != nil {
+		t.Fatalf("error creating snapshot: %s\n", err)
+	}
+
+	// Then
+	// All of the test files start with the same contents, so the first
+	// snapshot only has one chunk. The changed file adds one more.
+	if count := countChunks(t, repo); count != 2 {
+		t.Fatalf("wrong number of chunks stored: expected 2, got %d", count)
+	}
+}
+
+func TestPruneRepository(t *testing.T) {
+	// Given
+	dir := t.TempDir()
+	if err := setupTestDir(dir); err != nil {
+		t.Fatalf("error creating test dir: %s\n", err)
+	}
+
+	repo, err := OpenRepository(filepath.Join(t.TempDir(), "repo"))
+	if err != nil {
+		t.Fatalf("error opening repository: %s\n", err)
+	}
+
+	old, err := repo.Snapshot(dir)
+	if err != nil {
+		t.Fatalf("error creating snapshot: %s\n", err)
+	}
+
+	// Make the first snapshot look older, so the second one gets a new id
+	old.Time = old.Time.Add(-48 * time.Hour)
+	if err = repo.saveSnapshot(old); err != nil {
+		t.Fatalf("error saving snapshot: %s\n", err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, "file1.txt"), []byte("changed contents"), 0644); err != nil {
+		t.Fatalf("error changing test file: %s\n", err)
+	}
+	for _, file := range files[1:] {
+		if err = os.Remove(filepath.Join(dir, file)); err != nil {
+			t.Fatalf("error removing test file: %s\n", err)
+		}
+	}
+	time.Sleep(time.Second)
+	if _, err = repo.Snapshot(dir); err != nil {
+		t.Fatalf("error creating snapshot: %s\n", err)
+	}
+
+	// When
+	snapshots, chunks, err := repo.Prune(-1, 1)
+	if err != nil {
+		t.Fatalf("error pruning repository: %s\n", err)
+	}
+
+	// Then
+	if snapshots != 1 {
+		t.Fatalf("pruned wrong number of snapshots: expected 1, pruned %d", snapshots)
+	}
+	if chunks != 1 {
+		t.Fatalf("pruned wrong number of chunks: expected 1, pruned %d", chunks)
+	}
+	if count := countChunks(t, repo); count != 1 {
+		t.Fatalf("wrong number of chunks left: expected 1, got %d", count)
+	}
+}
+
+func TestPruneRepositoryDisabled(t *testing.T) {
+	dir := t.TempDir()
+	if err := setupTestDir(dir); err != nil {
+		t.Fatalf("error creating test dir: %s\n", err)
+	}
+
+	repo, err := OpenRepository(filepath.Join(t.TempDir(), "repo"))
+	if err != nil {
+		t.Fatalf("error opening repository: %s\n", err)
+	}
+	if _, err = repo.Snapshot(dir); err != nil {
+		t.Fatalf("error creating snapshot: %s\n", err)
+	}
+
+	for _, max := range []int{-1, 0} {
+		snapshots, chunks, err := repo.Prune(max, -1)
+		if err != nil {
+			t.Fatalf("error pruning repository: %s\n", err)
+		}
+		if snapshots != 0 || chunks != 0 {
+			t.Fatalf("expected nothing to be pruned with a limit of %d, pruned %d snapshots and %d chunks", max, snapshots, chunks)
+		}
+	}
+}
+
+func TestRestoreSnapshotRejectsEscapingPaths(t *testing.T) {
+	repo, err := OpenRepository(filepath.Join(t.TempDir(), "repo"))
+	if err != nil {
+		t.Fatalf("error opening repository: %s\n", err)
+	}
+
+	outside := t.TempDir()
+	cases := map[string][]SnapshotFile{
+		"escaping": {
+			{Path: "../evil.txt", Mode: 0644},
+		},
+		"symlink": {
+			{Path: "link", Mode: fs.ModeSymlink | 0777, Link: outside},
+			{Path: "link/evil.txt", Mode: 0644},
+		},
+	}
+
+	for id, files := range cases {
+		if err = repo.saveSnapshot(&Snapshot{ID: id, Time: time.Now(), Files: files}); err != nil {
+			t.Fatalf("error saving snapshot: %s\n", err)
+		}
+
+		dir := t.TempDir()
+		if err = repo.RestoreSnapshot(id, dir); err == nil {
+			t.Fatalf("expected an error restoring the %s snapshot", id)
+		}
+		if _, err = os.Stat(filepath.Join(outside, "evil.txt")); err == nil {
+			t.Fatalf("expected nothing to be written outside of the directory")
+		}
+		if _, err = os.Stat(filepath.Join(filepath.Dir(dir), "evil.txt")); err == nil {
+			t.Fatalf("expected nothing to be written outside of the directory")
+		}
+	}
+}
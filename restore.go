@@ -0,0 +1,355 @@
+package mcsmanager
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// OpenArchive opens a backup archive for reading, picking the right
+// decompressor based on the file extension.
+//
+// Closing the returned `io.Closer` closes both the decompressor and
+// the underlying file.
+func OpenArchive(path string) (*tar.Reader, io.Closer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dec, err := newDecompressor(file, filepath.Base(path))
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	return tar.NewReader(dec), multiCloser{dec, file}, nil
+}
+
+// Extract unpacks the file tree of a backup archive into the given directory.
+//
+// Entries that would be written outside of the directory, or through a
+// symlink that was extracted before them, are rejected.
+func Extract(archive, dir string) error {
+	r, closer, err := OpenArchive(archive)
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	for {
+		header, err := r.Next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+
+		path, err := safePath(root, header.Name)
+		if err != nil {
+			return err
+		}
+
+		mode := header.FileInfo().Mode()
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err = os.MkdirAll(path, mode.Perm()); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			if err = os.Symlink(header.Linkname, path); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			if err = extractFile(r, path, mode.Perm()); err != nil {
+				return fmt.Errorf("error extracting '%s': %s", header.Name, err)
+			}
+			if err = os.Chtimes(path, header.ModTime, header.ModTime); err != nil {
+				return err
+			}
+		default:
+			// Nothing else is created by the backup command
+			continue
+		}
+	}
+
+	return nil
+}
+
+// safePath joins a path from a backup to the absolute directory it is being
+// restored to. Paths outside of the directory are rejected, and so are paths
+// that go through a symlink that was restored before them, since a symlink
+// from a backup could point anywhere.
+func safePath(root, name string) (string, error) {
+	path := filepath.Join(root, name)
+	if path != root && !strings.HasPrefix(path, root+string(os.PathSeparator)) {
+		return "", fmt.Errorf("illegal file path in backup: %s", name)
+	}
+
+	through, err := throughSymlink(root, path)
+	if err != nil {
+		return "", err
+	}
+	if through {
+		return "", fmt.Errorf("illegal file path in backup, it goes through a symlink: %s", name)
+	}
+
+	return path, nil
+}
+
+// throughSymlink checks if any part of a path inside of root, including
+// the path itself, is a symlink.
+func throughSymlink(root, path string) (bool, error) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return false, err
+	}
+
+	current := root
+	for _, part := range strings.Split(rel, string(os.PathSeparator)) {
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return false, nil
+			}
+			return false, err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// extractFile copies the current archive entry into a new file.
+func extractFile(r io.Reader, path string, perm fs.FileMode) error {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err = io.Copy(file, r); err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+// Swap replaces the contents of a directory with the contents of
+// another directory, e.g. a freshly extracted backup.
+//
+// Any paths in the destination that match one of the exclusions, or a
+// pattern in the destination's ignore file, are left untouched along with
+// the directories that contain them. The old files are moved aside first,
+// and are put back if anything goes wrong. The source directory and the
+// old files are removed once everything has been moved over.
+func Swap(src, dst string, exclusions ...string) (err error) {
+	src = filepath.Clean(src)
+
+	matcher, err := LoadExclusions(dst, exclusions...)
+	if err != nil {
+		return err
+	}
+
+	old, err := os.MkdirTemp(dst, ".restore-old-")
+	if err != nil {
+		return err
+	}
+
+	s := swap{src: src, dst: dst, old: old}
+	defer func() {
+		if err != nil {
+			if rollbackErr := s.rollback(); rollbackErr != nil {
+				err = fmt.Errorf("%s, and the old files could not be put back: %s (they are in '%s')", err, rollbackErr, old)
+			}
+		}
+	}()
+
+	if err = s.moveAside(matcher); err != nil {
+		return err
+	}
+	if err = s.moveIn(); err != nil {
+		return err
+	}
+
+	if err = os.RemoveAll(old); err != nil {
+		return err
+	}
+
+	return os.RemoveAll(src)
+}
+
+// swap keeps track of the files that were moved while swapping two
+// directories, so they can be moved back.
+type swap struct {
+	src, dst, old string
+
+	// movedAside are the files moved from the destination to old
+	movedAside []string
+	// removedDirs are the empty directories removed from the destination
+	removedDirs []string
+	// movedIn are the files moved from the source to the destination
+	movedIn []string
+	// createdDirs are the directories created in the destination
+	createdDirs []string
+}
+
+// moveAside moves everything that isn't excluded from the destination into
+// the old directory, and removes the directories that are left empty.
+func (s *swap) moveAside(matcher *Matcher) error {
+	dirs := make([]string, 0)
+	err := fs.WalkDir(os.DirFS(s.dst), ".", func(child string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if child == "." {
+			return nil
+		}
+
+		path := filepath.Join(s.dst, child)
+		if path == s.src || path == s.old || matcher.Match(child, entry.IsDir()) {
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if entry.IsDir() {
+			dirs = append(dirs, child)
+			return nil
+		}
+
+		to := filepath.Join(s.old, child)
+		if err = os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+			return err
+		}
+		if err = os.Rename(path, to); err != nil {
+			return err
+		}
+		s.movedAside = append(s.movedAside, child)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Remove the leftover directories, deepest first. Directories that
+	// still hold excluded files are kept.
+	for i := len(dirs) - 1; i >= 0; i-- {
+		path := filepath.Join(s.dst, dirs[i])
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return err
+		}
+		if len(entries) > 0 {
+			continue
+		}
+		if err = os.Remove(path); err != nil {
+			return err
+		}
+		s.removedDirs = append(s.removedDirs, dirs[i])
+	}
+
+	return nil
+}
+
+// moveIn moves everything from the source into the destination.
+func (s *swap) moveIn() error {
+	return fs.WalkDir(os.DirFS(s.src), ".", func(child string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if child == "." {
+			return nil
+		}
+
+		from := filepath.Join(s.src, child)
+		to := filepath.Join(s.dst, child)
+
+		if entry.IsDir() {
+			if _, err := os.Lstat(to); err == nil {
+				return nil
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			if err = os.Mkdir(to, info.Mode().Perm()); err != nil {
+				return err
+			}
+			s.createdDirs = append(s.createdDirs, child)
+			return nil
+		}
+
+		if err = os.Rename(from, to); err != nil {
+			return err
+		}
+		s.movedIn = append(s.movedIn, child)
+		return nil
+	})
+}
+
+// rollback undoes a swap that failed partway, putting the old files back
+// where they were.
+func (s *swap) rollback() error {
+	for i := len(s.movedIn) - 1; i >= 0; i-- {
+		if err := os.Remove(filepath.Join(s.dst, s.movedIn[i])); err != nil {
+			return err
+		}
+	}
+	for i := len(s.createdDirs) - 1; i >= 0; i-- {
+		if err := os.Remove(filepath.Join(s.dst, s.createdDirs[i])); err != nil {
+			return err
+		}
+	}
+	for _, dir := range s.removedDirs {
+		if err := os.MkdirAll(filepath.Join(s.dst, dir), 0755); err != nil {
+			return err
+		}
+	}
+	for _, file := range s.movedAside {
+		to := filepath.Join(s.dst, file)
+		if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+			return err
+		}
+		if err := os.Rename(filepath.Join(s.old, file), to); err != nil {
+			return err
+		}
+	}
+
+	return os.RemoveAll(s.old)
+}
+
+// multiCloser closes several closers in order.
+type multiCloser []io.Closer
+
+// Close closes every closer, returning the first error encountered.
+func (m multiCloser) Close() (err error) {
+	for _, c := range m {
+		if cerr := c.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+
+	return
+}
@@ -0,0 +1,210 @@
+package mcsmanager
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExtractArchive(t *testing.T) {
+	// Create temp dir to test in
+	dir := t.TempDir()
+	if err := setupTestDir(dir); err != nil {
+		t.Fatalf("error creating test dir: %s\n", err)
+	}
+
+	// Archive the directory tree
+	archivePath := filepath.Join(t.TempDir(), "archive.tar")
+	tarFile, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("error creating archive file: %s\n", err)
+	}
+	w := tar.NewWriter(tarFile)
+	if _, err = Archive(dir, w); err != nil {
+		t.Fatalf("error writing file tree to archive: %s\n", err)
+	}
+	w.Close()
+	tarFile.Close()
+
+	// Extract it again
+	extractPath := t.TempDir()
+	if err = Extract(archivePath, extractPath); err != nil {
+		t.Fatalf("error extracting archive: %s\n", err)
+	}
+
+	if err = verifyFiles(dir, extractPath); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtractRejectsEscapingPaths(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "evil.tar")
+	tarFile, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("error creating archive file: %s\n", err)
+	}
+	w := tar.NewWriter(tarFile)
+	contents := []byte("nope")
+	w.WriteHeader(&tar.Header{Name: "../evil.txt", Mode: 0644, Size: int64(len(contents)), Typeflag: tar.TypeReg})
+	w.Write(contents)
+	w.Close()
+	tarFile.Close()
+
+	if err = Extract(archivePath, t.TempDir()); err == nil {
+		t.Fatal("expected an error extracting a file outside of the directory")
+	}
+}
+
+func TestExtractRejectsWritingThroughSymlinks(t *testing.T) {
+	outside := t.TempDir()
+	archivePath := filepath.Join(t.TempDir(), "evil.tar")
+	tarFile, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("error creating archive file: %s\n", err)
+	}
+	w := tar.NewWriter(tarFile)
+	contents := []byte("nope")
+	w.WriteHeader(&tar.Header{Name: "link", Linkname: outside, Mode: 0777, Typeflag: tar.TypeSymlink})
+	w.WriteHeader(&tar.Header{Name: "link/evil.txt", Mode: 0644, Size: int64(len(contents)), Typeflag: tar.TypeReg})
+	w.Write(contents)
+	w.Close()
+	tarFile.Close()
+
+	if err = Extract(archivePath, t.TempDir()); err == nil {
+		t.Fatal("expected an error extracting a file through a symlink")
+	}
+	if _, err = os.Stat(filepath.Join(outside, "evil.txt")); err == nil {
+		t.Fatal("expected nothing to be written outside of the directory")
+	}
+}
+
+func TestSwapKeepsExclusions(t *testing.T) {
+	// Given
+	src := t.TempDir()
+	if err := setupTestDir(src); err != nil {
+		t.Fatalf("error creating test dir: %s\n", err)
+	}
+
+	dst := t.TempDir()
+	for _, file := range []string{"stale.txt", filepath.Join("backups", "old.tar"), filepath.Join("nested", "keep.dat")} {
+		path := filepath.Join(dst, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("error creating test dir: %s\n", err)
+		}
+		if err := os.WriteFile(path, []byte("old contents"), 0644); err != nil {
+			t.Fatalf("error creating test file: %s\n", err)
+		}
+	}
+
+	// When
+	if err := Swap(src, dst, "backups", "*.dat"); err != nil {
+		t.Fatalf("error swapping directories: %s\n", err)
+	}
+
+	// Then
+	for _, file := range files {
+		contents, err := os.ReadFile(filepath.Join(dst, file))
+		if err != nil {
+			t.Fatalf("error reading swapped file: %s\n", err)
+		}
+		if string(contents) != "test file contents" {
+			t.Fatalf("file contents differ for file '%s': got '%s'", file, string(contents))
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dst, "stale.txt")); !os.IsNotExist(err) {
+		t.Fatal("file that isn't in the source was not removed")
+	}
+	for _, file := range []string{filepath.Join("backups", "old.tar"), filepath.Join("nested", "keep.dat")} {
+		if _, err := os.Stat(filepath.Join(dst, file)); err != nil {
+			t.Fatalf("expected file '%s' to exist: %s", file, err)
+		}
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Fatal("source directory was not removed")
+	}
+}
+
+func TestSwapRollsBack(t *testing.T) {
+	// Given
+	src := t.TempDir()
+	if err := setupTestDir(src); err != nil {
+		t.Fatalf("error creating test dir: %s\n", err)
+	}
+
+	// The backup has a file where the server has an excluded directory,
+	// so it can't be moved in. It is moved after the other files.
+	if err := os.WriteFile(filepath.Join(src, "worlds"), []byte("not a dir"), 0644); err != nil {
+		t.Fatalf("error creating test file: %s\n", err)
+	}
+
+	dst := t.TempDir()
+	old := map[string]string{
+		"file1.txt":                         "old contents",
+		"stale.txt":                         "stale contents",
+		filepath.Join("stale", "stale.txt"): "stale contents",
+		filepath.Join("worlds", "old.tar"):  "backup",
+	}
+	for file, contents := range old {
+		path := filepath.Join(dst, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("error creating test dir: %s\n", err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatalf("error creating test file: %s\n", err)
+		}
+	}
+
+	// When
+	if err := Swap(src, dst, "worlds"); err == nil {
+		t.Fatal("expected an error swapping a file onto a directory")
+	}
+
+	// Then
+	for file, expected := range old {
+		contents, err := os.ReadFile(filepath.Join(dst, file))
+		if err != nil || string(contents) != expected {
+			t.Fatalf("expected '%s' to be put back with '%s', got '%s' (%v)", file, expected, contents, err)
+		}
+	}
+	entries, err := os.ReadDir(dst)
+	if err != nil {
+		t.Fatalf("error reading dir: %s\n", err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("expected only the old files to be left, got %d entries", len(entries))
+	}
+}
+
+func TestFindBackup(t *testing.T) {
+	backups := make([]Backup, 0)
+	for _, name := range []string{"2021-09-01T10:00:00-0500.tar.gz", "2021-09-02T10:00:00-0500.tar.gz", "2021-09-02T22:00:00-0500.tar"} {
+		backups = append(backups, Backup{Name: name, Time: time.Now()})
+	}
+
+	cases := map[string]string{
+		"latest":                          "2021-09-02T22:00:00-0500.tar",
+		"2021-09-01":                      "2021-09-01T10:00:00-0500.tar.gz",
+		"2021-09-02T10:00:00-0500":        "2021-09-02T10:00:00-0500.tar.gz",
+		"2021-09-02T22:00:00-0500.tar":    "2021-09-02T22:00:00-0500.tar",
+		"2021-09-01T10:00:00-0500.tar.gz": "2021-09-01T10:00:00-0500.tar.gz",
+	}
+
+	for id, expected := range cases {
+		backup, err := FindBackup(backups, id)
+		if err != nil {
+			t.Fatalf("error finding backup '%s': %s", id, err)
+		}
+		if backup.Name != expected {
+			t.Fatalf("found wrong backup for '%s': expected %s, got %s", id, expected, backup.Name)
+		}
+	}
+
+	// Ambiguous and unknown ids should fail
+	for _, id := range []string{"2021-09-02", "2020"} {
+		if _, err := FindBackup(backups, id); err == nil {
+			t.Fatalf("expected an error finding backup '%s'", id)
+		}
+	}
+}
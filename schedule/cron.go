@@ -0,0 +1,212 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxLookahead is how far into the future we look for a matching time
+// before deciding that a cron expression never matches, e.g. `0 0 30 2 *`.
+const maxLookahead = 5 * 366 * 24 * time.Hour
+
+// shortcuts are the named cron expressions that we support.
+var shortcuts = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var dayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// field describes one of the five fields of a cron expression.
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var fields = []field{
+	{"minute", 0, 59, nil},
+	{"hour", 0, 23, nil},
+	{"day of month", 1, 31, nil},
+	{"month", 1, 12, monthNames},
+	{"day of week", 0, 7, dayNames},
+}
+
+// Cron is a parsed cron expression. Each field is stored as a bit set
+// of the values that it matches.
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+
+	// Like with cron, if both the day of month and day of week are
+	// restricted, a day matches if either of them match.
+	domStar, dowStar bool
+}
+
+// Parse parses a standard five-field cron expression, i.e.
+// `minute hour day-of-month month day-of-week`. Fields support `*`,
+// lists, ranges, steps, and month and day names. The shortcuts
+// `@hourly`, `@daily`, `@weekly`, `@monthly`, and `@yearly` are
+// supported as well.
+func Parse(expr string) (*Cron, error) {
+	expr = strings.TrimSpace(expr)
+	if shortcut, ok := shortcuts[strings.ToLower(expr)]; ok {
+		expr = shortcut
+	}
+
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("expected %d fields, found %d: '%s'", len(fields), len(parts), expr)
+	}
+
+	sets := make([]uint64, len(fields))
+	for i, part := range parts {
+		set, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, err
+		}
+		sets[i] = set
+	}
+
+	// Sunday can be either 0 or 7
+	dow := sets[4]
+	if dow&(1<<7) != 0 {
+		dow |= 1
+	}
+
+	return &Cron{
+		minute:  sets[0],
+		hour:    sets[1],
+		dom:     sets[2],
+		month:   sets[3],
+		dow:     dow,
+		domStar: strings.HasPrefix(parts[2], "*") || parts[2] == "?",
+		dowStar: strings.HasPrefix(parts[4], "*") || parts[4] == "?",
+	}, nil
+}
+
+// parseField parses a comma-separated list of values, ranges, and steps.
+func parseField(raw string, f field) (set uint64, err error) {
+	for _, part := range strings.Split(raw, ",") {
+		step, hasStep := 1, false
+		if i := strings.Index(part, "/"); i != -1 {
+			hasStep = true
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %s field: '%s'", f.name, part)
+			}
+			part = part[:i]
+		}
+
+		start, end := f.min, f.max
+		switch {
+		case part == "*" || part == "?":
+			// The whole range
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			if start, err = parseValue(bounds[0], f); err != nil {
+				return 0, err
+			}
+			if end, err = parseValue(bounds[1], f); err != nil {
+				return 0, err
+			}
+			if start > end {
+				return 0, fmt.Errorf("invalid range in %s field: '%s'", f.name, part)
+			}
+		default:
+			if start, err = parseValue(part, f); err != nil {
+				return 0, err
+			}
+			// A single value with a step runs until the end of the range
+			if !hasStep {
+				end = start
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+
+	return set, nil
+}
+
+// parseValue parses a single number or name in a field.
+func parseValue(raw string, f field) (int, error) {
+	if v, ok := f.names[strings.ToLower(raw)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(raw)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid value in %s field: '%s'", f.name, raw)
+	}
+
+	return v, nil
+}
+
+// Next returns the first time after the given time that matches the
+// expression, in the same location as the given time. If nothing matches
+// within the next few years, the zero time is returned.
+func (c *Cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxLookahead)
+
+	for t.Before(limit) {
+		if !has(c.month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !has(c.hour, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if !has(c.minute, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+// matchesDay checks if the day of a time matches the day of month and
+// day of week fields.
+func (c *Cron) matchesDay(t time.Time) bool {
+	dom := has(c.dom, t.Day())
+	dow := has(c.dow, int(t.Weekday()))
+
+	switch {
+	case c.domStar && c.dowStar:
+		return true
+	case c.domStar:
+		return dow
+	case c.dowStar:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+func has(set uint64, v int) bool {
+	return set&(1<<uint(v)) != 0
+}
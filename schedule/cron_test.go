@@ -0,0 +1,61 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	// A Wednesday
+	from := time.Date(2021, time.March, 3, 10, 17, 30, 0, time.UTC)
+
+	cases := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2021, time.March, 3, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2021, time.March, 3, 10, 30, 0, 0, time.UTC)},
+		{"5/20 * * * *", time.Date(2021, time.March, 3, 10, 25, 0, 0, time.UTC)},
+		{"0 4 * * *", time.Date(2021, time.March, 4, 4, 0, 0, 0, time.UTC)},
+		{"0 */6 * * *", time.Date(2021, time.March, 3, 12, 0, 0, 0, time.UTC)},
+		{"30 9-17 * * mon-fri", time.Date(2021, time.March, 3, 10, 30, 0, 0, time.UTC)},
+		{"0 12 * * sun", time.Date(2021, time.March, 7, 12, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2021, time.March, 7, 12, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2021, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * fri", time.Date(2021, time.March, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2021, time.March, 4, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2021, time.March, 3, 11, 0, 0, 0, time.UTC)},
+	}
+
+	for _, c := range cases {
+		cron, err := Parse(c.expr)
+		if err != nil {
+			t.Errorf("error parsing '%s': %s", c.expr, err)
+			continue
+		}
+
+		if actual := cron.Next(from); !actual.Equal(c.expected) {
+			t.Errorf("wrong next time for '%s': expected %s, got %s", c.expr, c.expected, actual)
+		}
+	}
+}
+
+func TestCronNeverMatches(t *testing.T) {
+	cron, err := Parse("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("error parsing expression: %s\n", err)
+	}
+
+	if next := cron.Next(time.Now()); !next.IsZero() {
+		t.Fatalf("expected no next time, got %s", next)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("expected an error parsing '%s'", expr)
+		}
+	}
+}
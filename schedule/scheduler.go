@@ -0,0 +1,116 @@
+package schedule
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Schedule decides when a job runs next.
+type Schedule interface {
+	// Next returns the first time after the given time that the job
+	// should run, or the zero time if it should never run again.
+	Next(t time.Time) time.Time
+}
+
+// Job is a task that runs on a schedule.
+type Job struct {
+	Name     string
+	Schedule Schedule
+
+	// Key groups jobs that must never run at the same time, e.g. all
+	// of the jobs for one server. A job waits for any other job with the
+	// same key to finish before it starts.
+	Key string
+
+	// Lead starts the job this long before each scheduled time, e.g. to
+	// warn players before a restart. The scheduled time is passed to Run.
+	Lead time.Duration
+
+	Run func(ctx context.Context, at time.Time) error
+}
+
+// Scheduler runs jobs on their schedules.
+type Scheduler struct {
+	jobs  []Job
+	locks map[string]chan struct{}
+
+	// OnError is called when a job returns an error.
+	OnError func(job Job, err error)
+}
+
+// New creates a scheduler without any jobs.
+func New() *Scheduler {
+	return &Scheduler{
+		jobs:  make([]Job, 0),
+		locks: make(map[string]chan struct{}),
+	}
+}
+
+// Add adds a job to the scheduler. Jobs must be added before calling Run.
+func (s *Scheduler) Add(job Job) {
+	if _, ok := s.locks[job.Key]; !ok {
+		s.locks[job.Key] = make(chan struct{}, 1)
+	}
+	s.jobs = append(s.jobs, job)
+}
+
+// Next returns the next time that a job will start, ignoring any
+// other jobs that it may need to wait for.
+func (s *Scheduler) Next(job Job, now time.Time) time.Time {
+	at := job.Schedule.Next(now.Add(job.Lead))
+	if at.IsZero() {
+		return at
+	}
+
+	return at.Add(-job.Lead)
+}
+
+// Run runs all of the jobs until the context is cancelled, and then waits
+// for any running jobs to finish. A job never overlaps with itself; if it
+// is still running when it should start again, that run is skipped.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, job := range s.jobs {
+		wg.Add(1)
+		go func(job Job) {
+			defer wg.Done()
+			s.loop(ctx, job)
+		}(job)
+	}
+
+	wg.Wait()
+}
+
+// loop runs a single job every time it is scheduled.
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	lock := s.locks[job.Key]
+	for {
+		start := s.Next(job, time.Now())
+		if start.IsZero() {
+			return
+		}
+
+		timer := time.NewTimer(time.Until(start))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		// Wait for other jobs with the same key to finish
+		select {
+		case <-ctx.Done():
+			return
+		case lock <- struct{}{}:
+		}
+
+		err := job.Run(ctx, start.Add(job.Lead))
+		<-lock
+
+		if err != nil && s.OnError != nil {
+			s.OnError(job, err)
+		}
+	}
+}
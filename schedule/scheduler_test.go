@@ -0,0 +1,70 @@
+package schedule
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// every is a schedule that runs at a fixed interval.
+type every time.Duration
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+func TestSchedulerSerializesJobs(t *testing.T) {
+	var mu sync.Mutex
+	running, maxRunning, runs := 0, 0, 0
+
+	run := func(ctx context.Context, at time.Time) error {
+		mu.Lock()
+		running++
+		runs++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	}
+
+	s := New()
+	s.Add(Job{Name: "backup", Key: "server", Schedule: every(5 * time.Millisecond), Run: run})
+	s.Add(Job{Name: "restart", Key: "server", Schedule: every(5 * time.Millisecond), Run: run})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	s.Run(ctx)
+
+	if runs < 2 {
+		t.Fatalf("expected jobs to run several times, ran %d times", runs)
+	}
+	if maxRunning != 1 {
+		t.Fatalf("jobs for the same server overlapped: %d ran at once", maxRunning)
+	}
+}
+
+func TestSchedulerLead(t *testing.T) {
+	s := New()
+	job := Job{Schedule: every(time.Hour), Lead: 10 * time.Minute}
+	now := time.Now()
+
+	if next := s.Next(job, now); !next.Equal(now.Add(time.Hour)) {
+		t.Fatalf("wrong start time: expected %s, got %s", now.Add(time.Hour), next)
+	}
+
+	cron, _ := Parse("0 4 * * *")
+	job.Schedule = cron
+	from := time.Date(2021, time.March, 3, 3, 55, 0, 0, time.UTC)
+	expected := time.Date(2021, time.March, 4, 3, 50, 0, 0, time.UTC)
+	if next := s.Next(job, from); !next.Equal(expected) {
+		t.Fatalf("wrong start time: expected %s, got %s", expected, next)
+	}
+}
@@ -0,0 +1,55 @@
+package supervisor
+
+import "time"
+
+// Backoff decides how long to wait before restarting a crashed server.
+//
+// The delay doubles with every restart in the last window, starting at
+// Min and going up to at most Max. Once MaxRestarts restarts happened in
+// the window, no more restarts are allowed until the oldest one is out
+// of the window.
+type Backoff struct {
+	Min         time.Duration
+	Max         time.Duration
+	Window      time.Duration
+	MaxRestarts int
+
+	restarts []time.Time
+}
+
+// Next returns how long to wait before the next restart. If there were
+// too many restarts recently, false is returned.
+func (b *Backoff) Next(now time.Time) (time.Duration, bool) {
+	// Forget about restarts that are out of the window
+	recent := make([]time.Time, 0, len(b.restarts))
+	for _, restart := range b.restarts {
+		if now.Sub(restart) < b.Window {
+			recent = append(recent, restart)
+		}
+	}
+	b.restarts = recent
+
+	if b.MaxRestarts > 0 && len(b.restarts) >= b.MaxRestarts {
+		return 0, false
+	}
+
+	delay := b.Min
+	for i := 0; i < len(b.restarts) && delay < b.Max; i++ {
+		delay *= 2
+	}
+	if b.Max > 0 && delay > b.Max {
+		delay = b.Max
+	}
+
+	return delay, true
+}
+
+// Restarted records that the server was restarted at the given time.
+func (b *Backoff) Restarted(at time.Time) {
+	b.restarts = append(b.restarts, at)
+}
+
+// Reset forgets about all previous restarts.
+func (b *Backoff) Reset() {
+	b.restarts = nil
+}
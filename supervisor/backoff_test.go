@@ -0,0 +1,32 @@
+package supervisor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	b := &Backoff{Min: 10 * time.Second, Max: time.Minute, Window: time.Hour, MaxRestarts: 4}
+	now := time.Now()
+
+	expected := []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute}
+	for i, e := range expected {
+		delay, ok := b.Next(now)
+		if !ok {
+			t.Fatalf("restart %d should be allowed", i+1)
+		}
+		if delay != e {
+			t.Fatalf("wrong delay for restart %d: expected %v, got %v", i+1, e, delay)
+		}
+		b.Restarted(now)
+	}
+
+	if _, ok := b.Next(now); ok {
+		t.Fatal("restart should not be allowed after too many restarts in the window")
+	}
+
+	// Once the restarts are out of the window, we start over
+	if delay, ok := b.Next(now.Add(time.Hour)); !ok || delay != 10*time.Second {
+		t.Fatalf("expected restarts to be allowed again with a 10s delay, got %v, %t", delay, ok)
+	}
+}
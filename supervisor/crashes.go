@@ -0,0 +1,159 @@
+package supervisor
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// CrashReportsDir is the directory that the server writes crash reports to.
+	CrashReportsDir = "crash-reports"
+
+	// HistoryFile is the name of the file in the server directory that
+	// holds the history of crashes.
+	HistoryFile = "crash-history.json"
+
+	// StopMarker is the name of a file in the server directory that shows
+	// that the server was stopped on purpose.
+	StopMarker = ".mcsmanager-stop"
+
+	// StopLine is logged by the server when it shuts down normally.
+	StopLine = "Stopping server"
+)
+
+// HangLine is logged by the Spigot watchdog when the server stops
+// responding. The vanilla watchdog shuts the server down by itself.
+const HangLine = "The server has stopped responding!"
+
+// Crash is an entry in the crash history.
+type Crash struct {
+	Time        time.Time `json:"time" yaml:"time"`
+	Reason      string    `json:"reason" yaml:"reason"`
+	Reports     []string  `json:"reports,omitempty" yaml:"reports,omitempty"`
+	Description string    `json:"description,omitempty" yaml:"description,omitempty"`
+	Restarted   bool      `json:"restarted" yaml:"restarted"`
+}
+
+// NewReports returns the names of the crash reports in the server
+// directory that were written after the given time, oldest first.
+func NewReports(dir string, since time.Time) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, CrashReportsDir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	type report struct {
+		name    string
+		modTime time.Time
+	}
+	reports := make([]report, 0)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".txt") {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		if info.ModTime().After(since) {
+			reports = append(reports, report{entry.Name(), info.ModTime()})
+		}
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].modTime.Before(reports[j].modTime)
+	})
+
+	names := make([]string, 0, len(reports))
+	for _, r := range reports {
+		names = append(names, r.name)
+	}
+
+	return names, nil
+}
+
+// ReportDescription returns the description of a crash report, e.g.
+// "Exception in server tick loop".
+func ReportDescription(dir, name string) (string, error) {
+	file, err := os.Open(filepath.Join(dir, CrashReportsDir, name))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "Description: ") {
+			return strings.TrimPrefix(line, "Description: "), nil
+		}
+	}
+
+	return "", scanner.Err()
+}
+
+// LoadHistory reads the crash history in the server directory. If there
+// is no history yet, an empty history is returned.
+func LoadHistory(dir string) ([]Crash, error) {
+	raw, err := os.ReadFile(filepath.Join(dir, HistoryFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []Crash{}, nil
+		}
+		return nil, err
+	}
+
+	history := make([]Crash, 0)
+	if err = json.Unmarshal(raw, &history); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// AddToHistory adds a crash to the end of the crash history in the
+// server directory.
+func AddToHistory(dir string, crash Crash) error {
+	history, err := LoadHistory(dir)
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.MarshalIndent(append(history, crash), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, HistoryFile), raw, 0644)
+}
+
+// MarkStopped records that the server is being stopped on purpose, so
+// the supervisor doesn't treat it as a crash.
+func MarkStopped(dir string) error {
+	return os.WriteFile(filepath.Join(dir, StopMarker), []byte(time.Now().Format(time.RFC3339)), 0644)
+}
+
+// WasStopped checks if the server was stopped on purpose.
+func WasStopped(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, StopMarker))
+	return err == nil
+}
+
+// ClearStopped removes the record of the server being stopped on purpose.
+func ClearStopped(dir string) error {
+	err := os.Remove(filepath.Join(dir, StopMarker))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,91 @@
+package supervisor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const crashReport = `---- Minecraft Crash Report ----
+// Don't do that.
+
+Time: 3/3/21, 10:17 AM
+Description: Exception in server tick loop
+
+java.lang.NullPointerException
+`
+
+func TestNewReports(t *testing.T) {
+	dir := t.TempDir()
+	reports := filepath.Join(dir, CrashReportsDir)
+	if err := os.Mkdir(reports, 0755); err != nil {
+		t.Fatalf("error creating crash reports dir: %s\n", err)
+	}
+
+	old := filepath.Join(reports, "crash-2021-03-02_10.00.00-server.txt")
+	if err := os.WriteFile(old, []byte(crashReport), 0644); err != nil {
+		t.Fatalf("error creating crash report: %s\n", err)
+	}
+	since := time.Now().Add(-time.Minute)
+	os.Chtimes(old, since.Add(-time.Hour), since.Add(-time.Hour))
+
+	name := "crash-2021-03-03_10.17.00-server.txt"
+	if err := os.WriteFile(filepath.Join(reports, name), []byte(crashReport), 0644); err != nil {
+		t.Fatalf("error creating crash report: %s\n", err)
+	}
+
+	found, err := NewReports(dir, since)
+	if err != nil {
+		t.Fatalf("error finding crash reports: %s\n", err)
+	}
+	if len(found) != 1 || found[0] != name {
+		t.Fatalf("found the wrong crash reports: %v", found)
+	}
+
+	description, err := ReportDescription(dir, name)
+	if err != nil {
+		t.Fatalf("error reading crash report: %s\n", err)
+	}
+	if description != "Exception in server tick loop" {
+		t.Fatalf("wrong description: '%s'", description)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	dir := t.TempDir()
+
+	for i := 0; i < 2; i++ {
+		if err := AddToHistory(dir, Crash{Time: time.Now(), Reason: "exited", Restarted: i == 0}); err != nil {
+			t.Fatalf("error adding to history: %s\n", err)
+		}
+	}
+
+	history, err := LoadHistory(dir)
+	if err != nil {
+		t.Fatalf("error loading history: %s\n", err)
+	}
+	if len(history) != 2 || !history[0].Restarted || history[1].Restarted {
+		t.Fatalf("wrong crash history: %+v", history)
+	}
+}
+
+func TestStopMarker(t *testing.T) {
+	dir := t.TempDir()
+
+	if WasStopped(dir) {
+		t.Fatal("server should not be marked as stopped")
+	}
+	if err := MarkStopped(dir); err != nil {
+		t.Fatalf("error marking server as stopped: %s\n", err)
+	}
+	if !WasStopped(dir) {
+		t.Fatal("server should be marked as stopped")
+	}
+	if err := ClearStopped(dir); err != nil {
+		t.Fatalf("error clearing stop marker: %s\n", err)
+	}
+	if WasStopped(dir) {
+		t.Fatal("stop marker should be cleared")
+	}
+}
@@ -0,0 +1,92 @@
+package target
+
+import (
+	"sort"
+	"time"
+
+	"github.com/EbonJaeger/mcsmanager"
+)
+
+// ListBackups finds all of the backup archives on a target. The returned
+// list is sorted so the oldest backup is first, and the path of each
+// backup is its remote name.
+func ListBackups(t Target) ([]mcsmanager.Backup, error) {
+	objects, err := t.List()
+	if err != nil {
+		return nil, err
+	}
+
+	backups := make([]mcsmanager.Backup, 0)
+	for _, object := range objects {
+		timestamp := mcsmanager.TrimArchiveExt(object.Name)
+		if timestamp == object.Name {
+			continue
+		}
+
+		created, err := time.Parse(mcsmanager.TimeFormat, timestamp)
+		if err != nil {
+			continue
+		}
+
+		backups = append(backups, mcsmanager.Backup{
+			Name: object.Name,
+			Path: object.Name,
+			Time: created,
+			Size: object.Size,
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Time.Before(backups[j].Time)
+	})
+
+	return backups, nil
+}
+
+// Prune removes backups from a target that are older than the max age in
+// days, and then the oldest backups until there are at most maxBackups left.
+// Either limit can be disabled by passing -1, and the number of backups by
+// passing 0 as well. The manifest of each archive is removed along with it.
+func Prune(t Target, maxBackups, maxAge int) (total int, err error) {
+	backups, err := ListBackups(t)
+	if err != nil {
+		return
+	}
+
+	objects, err := t.List()
+	if err != nil {
+		return
+	}
+	names := make(map[string]bool)
+	for _, object := range objects {
+		names[object.Name] = true
+	}
+
+	keep := make([]mcsmanager.Backup, 0, len(backups))
+	toRemove := make([]mcsmanager.Backup, 0)
+	for _, backup := range backups {
+		if maxAge != -1 && time.Since(backup.Time).Hours() > float64(maxAge*24) {
+			toRemove = append(toRemove, backup)
+			continue
+		}
+		keep = append(keep, backup)
+	}
+
+	if maxBackups > 0 && len(keep) > maxBackups { // -1 or 0 to disable the limit
+		toRemove = append(toRemove, keep[:len(keep)-maxBackups]...)
+	}
+
+	for _, backup := range toRemove {
+		if err = t.Remove(backup.Name); err != nil {
+			return
+		}
+		if manifest := mcsmanager.ManifestPath(backup.Name); names[manifest] {
+			if err = t.Remove(manifest); err != nil {
+				return
+			}
+		}
+		total++
+	}
+
+	return
+}
@@ -0,0 +1,103 @@
+package target
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Local is a backup target that copies backups into another directory.
+type Local struct {
+	Path string
+}
+
+// Upload copies a file into the target directory. The file is written
+// under a temporary name first, so a failed copy never looks like a
+// complete backup.
+func (l Local) Upload(path string) error {
+	if err := os.MkdirAll(l.Path, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(l.Path, ".upload-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err = copyFile(path, tmp); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(l.Path, filepath.Base(path)))
+}
+
+// List returns all of the files in the target directory.
+func (l Local) List() ([]Object, error) {
+	entries, err := os.ReadDir(l.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Object{}, nil
+		}
+		return nil, err
+	}
+
+	objects := make([]Object, 0)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, Object{
+			Name:    entry.Name(),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	}
+
+	return objects, nil
+}
+
+// Download copies a file from the target directory to the given path.
+func (l Local) Download(name, path string) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if err = copyFile(filepath.Join(l.Path, filepath.Base(name)), out); err != nil {
+		return err
+	}
+
+	return out.Close()
+}
+
+// Remove deletes a file from the target directory.
+func (l Local) Remove(name string) error {
+	return os.Remove(filepath.Join(l.Path, filepath.Base(name)))
+}
+
+// copyFile copies the contents of a file into a writer.
+func copyFile(path string, w io.Writer) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(w, file)
+	return err
+}
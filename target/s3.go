@@ -0,0 +1,391 @@
+package target
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// emptyHash is the `sha256` hash of an empty request body.
+const emptyHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// partSize is the size of each part of a multipart upload. Files larger
+// than this are uploaded in parts, since a single upload is limited to 5GB.
+var partSize int64 = 64 * 1024 * 1024
+
+// S3 is a backup target that stores backups in a bucket of an
+// S3-compatible object storage service, such as AWS S3 or MinIO.
+//
+// Requests use path-style URLs, i.e. `<endpoint>/<bucket>/<key>`, and
+// are signed with AWS Signature Version 4.
+type S3 struct {
+	Endpoint  string
+	Bucket    string
+	Region    string
+	Prefix    string
+	AccessKey string
+	SecretKey string
+
+	// Client is the HTTP client to use. If nil, `http.DefaultClient` is used.
+	Client *http.Client
+}
+
+// listBucketResult is the response to a ListObjectsV2 request.
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+		Size         int64     `xml:"Size"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// initiateMultipartUploadResult is the response to a CreateMultipartUpload request.
+type initiateMultipartUploadResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+// completeMultipartUpload is the request body to finish a multipart upload.
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// Upload puts a file into the bucket. Large files are uploaded in parts.
+func (s *S3) Upload(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	key := s.key(filepath.Base(path))
+	if info.Size() <= partSize {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return err
+		}
+		resp, err := s.do(http.MethodPut, key, nil, data)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+
+	return s.uploadParts(key, file)
+}
+
+// uploadParts uploads a file using a multipart upload. If any part
+// fails, the upload is aborted so the parts don't take up space.
+func (s *S3) uploadParts(key string, r io.Reader) (err error) {
+	resp, err := s.do(http.MethodPost, key, url.Values{"uploads": {""}}, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var upload initiateMultipartUploadResult
+	if err = xml.NewDecoder(resp.Body).Decode(&upload); err != nil {
+		return fmt.Errorf("unable to read multipart upload response: %s", err)
+	}
+
+	defer func() {
+		if err != nil {
+			if abort, abortErr := s.do(http.MethodDelete, key, url.Values{"uploadId": {upload.UploadID}}, nil); abortErr == nil {
+				abort.Body.Close()
+			}
+		}
+	}()
+
+	complete := completeMultipartUpload{}
+	buf := make([]byte, partSize)
+	for part := 1; ; part++ {
+		n, readErr := io.ReadFull(r, buf)
+		if n > 0 {
+			query := url.Values{
+				"partNumber": {strconv.Itoa(part)},
+				"uploadId":   {upload.UploadID},
+			}
+			partResp, err := s.do(http.MethodPut, key, query, buf[:n])
+			if err != nil {
+				return err
+			}
+			partResp.Body.Close()
+			complete.Parts = append(complete.Parts, completedPart{PartNumber: part, ETag: partResp.Header.Get("ETag")})
+		}
+
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+
+	body, err := xml.Marshal(complete)
+	if err != nil {
+		return err
+	}
+
+	done, err := s.do(http.MethodPost, key, url.Values{"uploadId": {upload.UploadID}}, body)
+	if err != nil {
+		return err
+	}
+	done.Body.Close()
+
+	return nil
+}
+
+// List returns all of the objects in the bucket under our prefix.
+func (s *S3) List() ([]Object, error) {
+	objects := make([]Object, 0)
+	token := ""
+
+	for {
+		query := url.Values{"list-type": {"2"}}
+		if s.Prefix != "" {
+			query.Set("prefix", s.Prefix)
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := s.do(http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("unable to read object list: %s", err)
+		}
+
+		for _, content := range result.Contents {
+			name := strings.TrimPrefix(content.Key, s.Prefix)
+			// Skip anything in a "subdirectory" of our prefix
+			if strings.Contains(name, "/") {
+				continue
+			}
+
+			objects = append(objects, Object{
+				Name:    name,
+				Size:    content.Size,
+				ModTime: content.LastModified,
+			})
+		}
+
+		if !result.IsTruncated {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+
+	return objects, nil
+}
+
+// Download gets an object from the bucket and writes it to the given path.
+func (s *S3) Download(name, path string) error {
+	resp, err := s.do(http.MethodGet, s.key(name), nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err = io.Copy(out, resp.Body); err != nil {
+		return err
+	}
+
+	return out.Close()
+}
+
+// Remove deletes an object from the bucket.
+func (s *S3) Remove(name string) error {
+	resp, err := s.do(http.MethodDelete, s.key(name), nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// key returns the full object key for a file name.
+func (s *S3) key(name string) string {
+	return s.Prefix + name
+}
+
+// do sends a signed request for an object key, or for the bucket itself if
+// the key is empty. An error is returned if the response isn't a 2xx status.
+func (s *S3) do(method, key string, query url.Values, body []byte) (*http.Response, error) {
+	path := "/" + s.Bucket
+	if key != "" {
+		path += "/" + key
+	}
+
+	u, err := url.Parse(s.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = u.Path + path
+	u.RawPath = encodePath(u.Path)
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+
+	s.sign(req, body, time.Now().UTC())
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s failed: %d %s", method, path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	return resp, nil
+}
+
+// sign adds an AWS Signature Version 4 authorization header to a request.
+func (s *S3) sign(req *http.Request, body []byte, now time.Time) {
+	payloadHash := emptyHash
+	if len(body) > 0 {
+		sum := sha256.Sum256(body)
+		payloadHash = hex.EncodeToString(sum[:])
+	}
+
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	// Build the canonical headers
+	signed := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	sort.Strings(signed)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range signed {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(signed, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		encodePath(req.URL.Path),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, s.Region, "s3", "aws4_request"}, "/")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	// Derive the signing key
+	key := hmacSHA256([]byte("AWS4"+s.SecretKey), date)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", s.AccessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery encodes a query string with sorted keys, escaping
+// spaces as `%20` as required by the signing process.
+func canonicalQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, uriEncode(k)+"="+uriEncode(v))
+		}
+	}
+
+	return strings.Join(parts, "&")
+}
+
+// encodePath URI-encodes each segment of a path.
+func encodePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// uriEncode escapes everything except unreserved characters.
+func uriEncode(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
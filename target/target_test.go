@@ -0,0 +1,240 @@
+package target
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EbonJaeger/mcsmanager"
+)
+
+// fakeS3 is a tiny in-memory stand-in for an S3-compatible service.
+type fakeS3 struct {
+	sync.Mutex
+	objects map[string][]byte
+	parts   map[string]map[int][]byte
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{
+		objects: make(map[string][]byte),
+		parts:   make(map[string]map[int][]byte),
+	}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	// Path is /<bucket>/<key>
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	if parts[0] != "bucket" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	key := ""
+	if len(parts) == 2 {
+		key = parts[1]
+	}
+
+	query := r.URL.Query()
+	body, _ := io.ReadAll(r.Body)
+
+	switch {
+	case r.Method == http.MethodGet && key == "":
+		result := struct {
+			XMLName  xml.Name `xml:"ListBucketResult"`
+			Contents []struct {
+				Key          string
+				LastModified time.Time
+				Size         int
+			}
+		}{}
+		keys := make([]string, 0)
+		for k := range f.objects {
+			if strings.HasPrefix(k, query.Get("prefix")) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			result.Contents = append(result.Contents, struct {
+				Key          string
+				LastModified time.Time
+				Size         int
+			}{k, time.Now(), len(f.objects[k])})
+		}
+		xml.NewEncoder(w).Encode(result)
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		f.parts[key] = make(map[int][]byte)
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>", key)
+	case r.Method == http.MethodPut && query.Has("partNumber"):
+		n, _ := strconv.Atoi(query.Get("partNumber"))
+		f.parts[key][n] = body
+		w.Header().Set("ETag", fmt.Sprintf("\"%d\"", n))
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		data := make([]byte, 0)
+		for i := 1; i <= len(f.parts[key]); i++ {
+			data = append(data, f.parts[key][i]...)
+		}
+		f.objects[key] = data
+		delete(f.parts, key)
+	case r.Method == http.MethodPut:
+		f.objects[key] = body
+	case r.Method == http.MethodGet:
+		data, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+// createBackups creates fake backup archives in a directory, one per day
+// starting at the given number of days ago.
+func createBackups(t *testing.T, dir string, days int) []string {
+	paths := make([]string, 0, days)
+	for i := days; i > 0; i-- {
+		name := time.Now().Add(time.Duration(-i) * 24 * time.Hour).Format(mcsmanager.TimeFormat)
+		path := filepath.Join(dir, name+".tar.gz")
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("error creating test backup: %s\n", err)
+		}
+		paths = append(paths, path)
+	}
+
+	return paths
+}
+
+// testTarget uploads, lists, downloads, and prunes backups on a target.
+func testTarget(t *testing.T, target Target) {
+	paths := createBackups(t, t.TempDir(), 4)
+	for _, path := range paths {
+		if err := target.Upload(path); err != nil {
+			t.Fatalf("error uploading backup: %s\n", err)
+		}
+	}
+
+	backups, err := ListBackups(target)
+	if err != nil {
+		t.Fatalf("error listing backups: %s\n", err)
+	}
+	if len(backups) != len(paths) {
+		t.Fatalf("wrong number of backups: expected %d, got %d", len(paths), len(backups))
+	}
+
+	// Download the newest backup
+	out := filepath.Join(t.TempDir(), "download.tar.gz")
+	if err = target.Download(backups[3].Name, out); err != nil {
+		t.Fatalf("error downloading backup: %s\n", err)
+	}
+	expected, _ := os.ReadFile(paths[3])
+	actual, _ := os.ReadFile(out)
+	if string(expected) != string(actual) {
+		t.Fatalf("downloaded backup differs: expected '%s', got '%s'", expected, actual)
+	}
+
+	// The oldest backup is too old, and then one more is over the limit
+	pruned, err := Prune(target, 2, 3)
+	if err != nil {
+		t.Fatalf("error pruning backups: %s\n", err)
+	}
+	if pruned != 2 {
+		t.Fatalf("pruned wrong number of backups: expected 2, pruned %d", pruned)
+	}
+
+	backups, err = ListBackups(target)
+	if err != nil {
+		t.Fatalf("error listing backups: %s\n", err)
+	}
+	if len(backups) != 2 || backups[1].Name != filepath.Base(paths[3]) {
+		t.Fatalf("wrong backups left after pruning: %v", backups)
+	}
+}
+
+func TestPruneDisabled(t *testing.T) {
+	target := Local{Path: filepath.Join(t.TempDir(), "mirror")}
+	for _, path := range createBackups(t, t.TempDir(), 3) {
+		if err := target.Upload(path); err != nil {
+			t.Fatalf("error uploading backup: %s\n", err)
+		}
+	}
+
+	for _, max := range []int{-1, 0} {
+		pruned, err := Prune(target, max, -1)
+		if err != nil {
+			t.Fatalf("error pruning backups: %s\n", err)
+		}
+		if pruned != 0 {
+			t.Fatalf("expected nothing to be pruned with a limit of %d, pruned %d", max, pruned)
+		}
+	}
+}
+
+func TestLocalTarget(t *testing.T) {
+	testTarget(t, Local{Path: filepath.Join(t.TempDir(), "mirror")})
+}
+
+func TestS3Target(t *testing.T) {
+	server := httptest.NewServer(newFakeS3())
+	defer server.Close()
+
+	testTarget(t, &S3{
+		Endpoint:  server.URL,
+		Bucket:    "bucket",
+		Region:    "us-east-1",
+		Prefix:    "server1/",
+		AccessKey: "access",
+		SecretKey: "secret",
+	})
+}
+
+func TestS3MultipartUpload(t *testing.T) {
+	server := httptest.NewServer(newFakeS3())
+	defer server.Close()
+
+	oldPartSize := partSize
+	partSize = 8
+	defer func() { partSize = oldPartSize }()
+
+	s := &S3{Endpoint: server.URL, Bucket: "bucket", Region: "us-east-1", AccessKey: "access", SecretKey: "secret"}
+
+	path := filepath.Join(t.TempDir(), "big.tar")
+	contents := "this file is split into several parts"
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("error creating test file: %s\n", err)
+	}
+
+	if err := s.Upload(path); err != nil {
+		t.Fatalf("error uploading file: %s\n", err)
+	}
+
+	out := filepath.Join(t.TempDir(), "download.tar")
+	if err := s.Download("big.tar", out); err != nil {
+		t.Fatalf("error downloading file: %s\n", err)
+	}
+	if actual, _ := os.ReadFile(out); string(actual) != contents {
+		t.Fatalf("downloaded file differs: expected '%s', got '%s'", contents, actual)
+	}
+}
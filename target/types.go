@@ -0,0 +1,72 @@
+package target
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/EbonJaeger/mcsmanager/config"
+)
+
+const (
+	// LocalTarget is a backup target that copies backups to another
+	// directory, such as a second disk or an NFS mount.
+	LocalTarget = "LOCAL"
+
+	// S3Target is a backup target that uploads backups to an
+	// S3-compatible object storage service.
+	S3Target = "S3"
+)
+
+// Target is an interface for a place that backups are copied to,
+// in addition to the backup directory.
+type Target interface {
+	// Upload copies a local file to the target, using the file's
+	// base name as its remote name.
+	Upload(path string) error
+
+	// List returns every file stored on the target.
+	List() ([]Object, error)
+
+	// Download copies a remote file to a local path.
+	Download(name, path string) error
+
+	// Remove deletes a remote file.
+	Remove(name string) error
+}
+
+// Object describes a file stored on a backup target.
+type Object struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
+// MatchTarget creates and returns a backup target for the given settings.
+func MatchTarget(settings config.TargetSettings) (Target, error) {
+	switch strings.ToUpper(settings.Type) {
+	case LocalTarget:
+		if settings.Path == "" {
+			return nil, fmt.Errorf("no path set for local target '%s'", settings.Name)
+		}
+		return Local{Path: settings.Path}, nil
+	case S3Target:
+		if settings.Endpoint == "" || settings.Bucket == "" {
+			return nil, fmt.Errorf("endpoint and bucket must be set for S3 target '%s'", settings.Name)
+		}
+		region := settings.Region
+		if region == "" {
+			region = "us-east-1"
+		}
+		return &S3{
+			Endpoint:  strings.TrimSuffix(settings.Endpoint, "/"),
+			Bucket:    settings.Bucket,
+			Region:    region,
+			Prefix:    settings.Prefix,
+			AccessKey: settings.AccessKey,
+			SecretKey: settings.SecretKey,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown backup target type: %s", settings.Type)
+	}
+}
@@ -3,6 +3,7 @@ package tmux
 import (
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 	"syscall"
 )
@@ -34,22 +35,29 @@ func Attach(name string) error {
 	return nil
 }
 
-// CreateSession starts a named tmux session that runs a single command.
-// If a session is already active, a new window for the server will be created.
-func CreateSession(command, name string) ([]byte, error) {
+// CreateSession starts a named tmux session that runs a single command in
+// the given directory. If a session is already active, a new window for the
+// server will be created.
+func CreateSession(command, name, dir string) ([]byte, error) {
 	var cmd *exec.Cmd
 	if IsSessionRunning() {
-		cmd = exec.Command("tmux", "new-window", "-d", "-t", sessionName, "-n", name, command)
+		cmd = exec.Command("tmux", "new-window", "-d", "-t", sessionName, "-n", name, "-c", dir, command)
 	} else {
-		cmd = exec.Command("tmux", "new-session", "-d", "-s", sessionName, "-n", name, command)
+		cmd = exec.Command("tmux", "new-session", "-d", "-s", sessionName, "-n", name, "-c", dir, command)
 	}
 
 	return cmd.Output()
 }
 
-// Exec creates a command to send keys to the tmux session.
+// Exec creates a command to send keys to the tmux session. The command is
+// sent as literal text, so quotes (e.g. in JSON text for `title` or `tellraw`)
+// reach the server unchanged.
 func Exec(command, name string) error {
-	cmd := exec.Command("tmux", "send-keys", "-t", getWindow(name), strings.Replace(command, "\"", "\\\"", -1), "C-m")
+	if err := exec.Command("tmux", "send-keys", "-l", "-t", getWindow(name), command).Run(); err != nil {
+		return err
+	}
+
+	cmd := exec.Command("tmux", "send-keys", "-t", getWindow(name), "C-m")
 
 	return cmd.Run()
 }
@@ -102,6 +110,17 @@ func ListWindows() (string, error) {
 	return string(out), err
 }
 
+// PanePID gets the PID of the process running in a window.
+func PanePID(name string) (int, error) {
+	out, err := exec.Command("tmux", "list-panes", "-t", getWindow(name), "-F", "#{pane_pid}").Output()
+	if err != nil {
+		return 0, err
+	}
+
+	// A window only has one pane
+	return strconv.Atoi(strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0]))
+}
+
 // KillWindow closes an active tmux window.
 func KillWindow(name string) error {
 	cmd := exec.Command("tmux", "kill-window", "-t", getWindow(name))
@@ -0,0 +1,160 @@
+package mcsmanager
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// manifestExt is the extension of the manifest file written next to
+// each backup archive.
+const manifestExt = ".manifest.json"
+
+// Manifest lists every file in a backup archive along with its size
+// and checksum, keyed by the path of the file in the archive.
+type Manifest struct {
+	Files map[string]ManifestEntry `json:"files"`
+}
+
+// ManifestEntry holds the size and `sha256` hash of a single file.
+type ManifestEntry struct {
+	Size int64  `json:"size"`
+	Hash string `json:"sha256"`
+}
+
+// Verification is the result of checking an archive against its manifest.
+type Verification struct {
+	// Missing holds files that are in the manifest, but not the archive.
+	Missing []string
+
+	// Corrupt holds files whose size or checksum doesn't match the manifest.
+	Corrupt []string
+
+	// Extra holds files that are in the archive, but not the manifest.
+	Extra []string
+}
+
+// OK returns true if no problems were found.
+func (v Verification) OK() bool {
+	return len(v.Missing) == 0 && len(v.Corrupt) == 0 && len(v.Extra) == 0
+}
+
+// NewManifest creates an empty manifest.
+func NewManifest() *Manifest {
+	return &Manifest{Files: make(map[string]ManifestEntry)}
+}
+
+// ManifestPath returns the path to the manifest for a backup archive.
+func ManifestPath(archive string) string {
+	return TrimArchiveExt(archive) + manifestExt
+}
+
+// LoadManifest reads a manifest from a file.
+func LoadManifest(path string) (*Manifest, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	m := NewManifest()
+	if err = json.NewDecoder(file).Decode(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Save writes a manifest to a file on disk.
+func (m Manifest) Save(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err = json.NewEncoder(file).Encode(m); err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+// VerifyArchive reads an entire archive, checking that the tar and
+// compression framing is intact. If a manifest is given, the size and
+// checksum of every file is compared against it.
+//
+// An error is returned if the archive can't be read to the end, e.g.
+// because it is truncated.
+func VerifyArchive(path string, manifest *Manifest) (*Verification, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	dec, err := newDecompressor(file, path)
+	if err != nil {
+		return nil, err
+	}
+	defer dec.Close()
+
+	result := &Verification{}
+	seen := make(map[string]bool)
+	r := tar.NewReader(dec)
+	for {
+		header, err := r.Next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		// Hash the file, which also reads all of its compressed data
+		hash := sha256.New()
+		n, err := io.Copy(hash, r)
+		if err != nil {
+			return nil, fmt.Errorf("error reading '%s': %s", header.Name, err)
+		}
+
+		if manifest == nil {
+			continue
+		}
+
+		seen[header.Name] = true
+		expected, ok := manifest.Files[header.Name]
+		switch {
+		case !ok:
+			result.Extra = append(result.Extra, header.Name)
+		case expected.Size != n || expected.Hash != hex.EncodeToString(hash.Sum(nil)):
+			result.Corrupt = append(result.Corrupt, header.Name)
+		}
+	}
+
+	// Read anything after the end of the tar stream, so the
+	// decompressor checks its trailing checksum.
+	if _, err = io.Copy(io.Discard, dec); err != nil {
+		return nil, err
+	}
+
+	if manifest != nil {
+		for name := range manifest.Files {
+			if !seen[name] {
+				result.Missing = append(result.Missing, name)
+			}
+		}
+		sort.Strings(result.Missing)
+	}
+
+	return result, nil
+}
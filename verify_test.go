@@ -0,0 +1,99 @@
+package mcsmanager
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// createTestArchive archives a test file tree into a gzip archive,
+// returning the path to the archive and its manifest.
+func createTestArchive(t *testing.T) (string, *Manifest) {
+	dir := t.TempDir()
+	if err := setupTestDir(dir); err != nil {
+		t.Fatalf("error creating test dir: %s\n", err)
+	}
+
+	archivePath := filepath.Join(t.TempDir(), "archive.tar.gz")
+	file, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("error creating archive file: %s\n", err)
+	}
+	compressor, err := NewCompressor(file, CompressionGzip, CompressionOptions{})
+	if err != nil {
+		t.Fatalf("error creating compressor: %s\n", err)
+	}
+	w := tar.NewWriter(compressor)
+	manifest, err := Archive(dir, w)
+	if err != nil {
+		t.Fatalf("error writing file tree to archive: %s\n", err)
+	}
+	w.Close()
+	compressor.Close()
+	file.Close()
+
+	return archivePath, manifest
+}
+
+func TestVerifyArchive(t *testing.T) {
+	archivePath, manifest := createTestArchive(t)
+
+	if len(manifest.Files) != len(files) {
+		t.Fatalf("wrong number of files in manifest: expected %d, got %d", len(files), len(manifest.Files))
+	}
+
+	result, err := VerifyArchive(archivePath, manifest)
+	if err != nil {
+		t.Fatalf("error verifying archive: %s\n", err)
+	}
+	if !result.OK() {
+		t.Fatalf("expected archive to be OK, got %+v", result)
+	}
+}
+
+func TestVerifyArchiveMismatch(t *testing.T) {
+	// Given
+	archivePath, manifest := createTestArchive(t)
+
+	entry := manifest.Files["file1.txt"]
+	entry.Hash = "bad"
+	manifest.Files["file1.txt"] = entry
+	delete(manifest.Files, "file2.txt")
+	manifest.Files["gone.txt"] = ManifestEntry{Size: 1, Hash: "bad"}
+
+	// When
+	result, err := VerifyArchive(archivePath, manifest)
+	if err != nil {
+		t.Fatalf("error verifying archive: %s\n", err)
+	}
+
+	// Then
+	if len(result.Corrupt) != 1 || result.Corrupt[0] != "file1.txt" {
+		t.Fatalf("expected file1.txt to be corrupt, got %v", result.Corrupt)
+	}
+	if len(result.Extra) != 1 || result.Extra[0] != "file2.txt" {
+		t.Fatalf("expected file2.txt to be extra, got %v", result.Extra)
+	}
+	if len(result.Missing) != 1 || result.Missing[0] != "gone.txt" {
+		t.Fatalf("expected gone.txt to be missing, got %v", result.Missing)
+	}
+}
+
+func TestVerifyTruncatedArchive(t *testing.T) {
+	// Given
+	archivePath, manifest := createTestArchive(t)
+
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		t.Fatalf("error reading archive: %s\n", err)
+	}
+	if err = os.Truncate(archivePath, info.Size()-10); err != nil {
+		t.Fatalf("error truncating archive: %s\n", err)
+	}
+
+	// Then
+	if _, err = VerifyArchive(archivePath, manifest); err == nil {
+		t.Fatal("expected an error verifying a truncated archive")
+	}
+}
@@ -0,0 +1,37 @@
+package mcsmanager
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/EbonJaeger/mcsmanager/properties"
+)
+
+// DefaultLevelName is the name of the main world if server.properties
+// doesn't set one.
+const DefaultLevelName = "world"
+
+// worldSuffixes are added to the level name for the nether and the end.
+// Bukkit-based servers keep these in their own directories next to the
+// main world, while vanilla keeps them inside of it.
+var worldSuffixes = []string{"", "_nether", "_the_end"}
+
+// Worlds finds the world directories of a server, using the `level-name`
+// from server.properties. Only directories that exist are returned.
+func Worlds(path string) ([]string, error) {
+	props, err := properties.Load(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	level := props.Get("level-name", DefaultLevelName)
+
+	worlds := make([]string, 0, len(worldSuffixes))
+	for _, suffix := range worldSuffixes {
+		info, err := os.Stat(filepath.Join(path, level+suffix))
+		if err == nil && info.IsDir() {
+			worlds = append(worlds, level+suffix)
+		}
+	}
+
+	return worlds, nil
+}
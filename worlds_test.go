@@ -0,0 +1,47 @@
+package mcsmanager
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWorlds(t *testing.T) {
+	dir := t.TempDir()
+	for _, world := range []string{"survival", "survival_nether", "world"} {
+		if err := os.Mkdir(filepath.Join(dir, world), 0755); err != nil {
+			t.Fatalf("error creating world dir: %s\n", err)
+		}
+	}
+	props := "#Minecraft server properties\nlevel-name=survival\nmotd=Hello=World\n"
+	if err := os.WriteFile(filepath.Join(dir, "server.properties"), []byte(props), 0644); err != nil {
+		t.Fatalf("error creating server.properties: %s\n", err)
+	}
+
+	worlds, err := Worlds(dir)
+	if err != nil {
+		t.Fatalf("error finding worlds: %s\n", err)
+	}
+
+	expected := []string{"survival", "survival_nether"}
+	if !reflect.DeepEqual(worlds, expected) {
+		t.Fatalf("found the wrong worlds: expected %v, got %v", expected, worlds)
+	}
+}
+
+func TestWorldsDefaultLevelName(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, DefaultLevelName), 0755); err != nil {
+		t.Fatalf("error creating world dir: %s\n", err)
+	}
+
+	worlds, err := Worlds(dir)
+	if err != nil {
+		t.Fatalf("error finding worlds: %s\n", err)
+	}
+
+	if len(worlds) != 1 || worlds[0] != DefaultLevelName {
+		t.Fatalf("found the wrong worlds: expected [%s], got %v", DefaultLevelName, worlds)
+	}
+}